@@ -0,0 +1,8 @@
+package main
+
+// Драйверы СУБД для выгрузки и загрузки данных (pkg/sqldb)
+import (
+	_ "github.com/lib/pq"               // postgres
+	_ "github.com/microsoft/go-mssqldb" // sqlserver
+	_ "modernc.org/sqlite"              // sqlite
+)
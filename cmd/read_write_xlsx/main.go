@@ -2,37 +2,221 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"read_write_xlsx/internal/config"
 	"read_write_xlsx/internal/services"
+	"strings"
 
 	"read_write_xlsx/internal/glogger"
 )
 
+// command подкоманда программы
+type command struct {
+	name      string
+	usage     string
+	readSheet bool // -sheet - лист чтения (read_file_settings.sheet_name), иначе флаг передаётся команде
+	run       func(s *services.App, filename string, o *options) error
+}
+
+var commands = []command{
+	{"run", "чтение, запись листа \"Вывод\", сводные таблицы и сводки (по умолчанию)", true, runCmd},
+	{"read", "чтение записей и выгрузка в JSON/NDJSON/CSV (по умолчанию <имя>.ndjson)", true, func(s *services.App, filename string, o *options) error {
+		return s.Read(filename)
+	}},
+	{"write", "запись данных JSON/NDJSON/CSV по write_file_settings на лист -sheet", false, func(s *services.App, filename string, o *options) error {
+		return s.Write(filename, o.sheet)
+	}},
+	{"pivot", "сводные таблицы из конфига, -sheet - лист исходных данных", false, func(s *services.App, filename string, o *options) error {
+		return s.Pivot(filename, o.sheet)
+	}},
+	{"convert", "перевод записей в формат файла -out (xlsx, csv, tsv, json, ndjson)", true, func(s *services.App, filename string, o *options) error {
+		return s.Convert(filename)
+	}},
+	{"inspect", "листы файла, первые -rows строк, типы колонок и описание полей для конфига", false, func(s *services.App, filename string, o *options) error {
+		return s.Inspect(filename, o.sheet, o.rows)
+	}},
+	{"validate", "проверка всех записей по правилам полей, отчёт в errors_file", true, func(s *services.App, filename string, o *options) error {
+		return s.Validate(filename)
+	}},
+}
+
+// options флаги командной строки, заданные флаги заменяют значения конфига
+type options struct {
+	config    string
+	sheet     string
+	startRow  int
+	out       string
+	overwrite bool
+	logLevel  string
+	logType   string
+	rows      int
+	set       setFlags
+	version   bool
+}
+
 func main() {
+	args := os.Args[1:]
+	cmd := commands[0]
+	if len(args) > 0 {
+		if c, ok := findCommand(args[0]); ok {
+			cmd = c
+			args = args[1:]
+		} else if args[0] == "help" {
+			usage(os.Stdout)
+			return
+		}
+	}
 
-	if len(os.Args) < 2 {
+	o := &options{}
+	fs := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	fs.Usage = func() { usage(fs.Output()) }
+	fs.StringVar(&o.config, "config", "config.json", "файл конфига")
+	fs.StringVar(&o.sheet, "sheet", "", "лист чтения (для write - лист вывода, для pivot - лист исходных данных)")
+	fs.IntVar(&o.startRow, "start-row", 0, "строка начала данных")
+	fs.StringVar(&o.out, "out", "", "файл результата (по умолчанию <имя>_out.xlsx)")
+	fs.BoolVar(&o.overwrite, "overwrite", false, "разрешить запись результата во входной файл")
+	fs.StringVar(&o.logLevel, "log-level", "", "уровень логирования: debug, info, warn, error")
+	fs.StringVar(&o.logType, "log-type", "", "логгер: zap, logrus")
+	fs.IntVar(&o.rows, "rows", 10, "сколько первых строк показать (inspect)")
+	fs.Var(&o.set, "set", "задать параметр конфига: путь=значение (можно несколько раз)")
+	fs.BoolVar(&o.version, "version", false, "показать версию и выйти")
+	files := parseArgs(fs, args)
+
+	if o.version {
+		fmt.Println(config.ShowVersion())
+		return
+	}
+	if len(files) < 1 {
 		fmt.Println("Файл не задан")
-		fmt.Println("Используйте: " + os.Args[0] + " <Имя файла>")
+		usage(os.Stdout)
 		os.Exit(1)
 	}
-	filename := os.Args[1]
+	filename := files[0]
 
-	cfgPath := "config.json"
-	cfg, err := config.LoadConfig(cfgPath)
+	cfgPath := o.config
+	if cmd.name == "inspect" && !flagSet(fs, "config") {
+		if _, err := os.Stat(cfgPath); os.IsNotExist(err) {
+			cfgPath = "" // inspect нужен и для нового файла, для которого конфига ещё нет
+		}
+	}
+	cfg, err := config.LoadConfig(cfgPath, o.sets(cmd)...)
 	if err != nil {
 		log.Fatal("cannot load config:", err)
 	}
 
-	logger := glogger.BuildLogger("", cfg.LogLevel) // STD LOGRUS ZAP
+	logger := glogger.BuildLogger(cfg.LogType, cfg.LogLevel) // STD LOGRUS ZAP
 
 	logger.Debugf("%v", cfg)
 
 	s := services.New(cfg, logger)
-	if err := s.Run(filename); err != nil {
-		logger.Fatal("services.Run:", err)
+	if err := cmd.run(s, filename, o); err != nil {
+		logger.Fatalf("%v: %v", cmd.name, err)
+	}
+}
+
+// runCmd полный цикл обработки файла или вывод результата запроса db_import
+func runCmd(s *services.App, filename string, o *options) error {
+	if s.QueryMode() { // файл - результат запроса к БД
+		return s.RunQuery(filename)
+	}
+	return s.Run(filename)
+}
+
+// sets флаги как присваивания параметров конфига (см. config.Config.Set):
+// сначала -set по порядку, затем отдельные флаги
+func (o *options) sets(cmd command) []string {
+	sets := append([]string{}, o.set...)
+	if o.sheet != "" && cmd.readSheet {
+		sets = append(sets, "read_file_settings.sheet_name="+o.sheet)
+	}
+	if o.startRow > 0 {
+		sets = append(sets, fmt.Sprintf("read_file_settings.start_row=%d", o.startRow))
+	}
+	if o.out != "" {
+		sets = append(sets, "output.file="+o.out)
+	}
+	if o.overwrite {
+		sets = append(sets, "output.overwrite_input=true")
+	}
+	if o.logLevel != "" {
+		sets = append(sets, "log_level="+o.logLevel)
+	}
+	if o.logType != "" {
+		sets = append(sets, "log_type="+o.logType)
+	}
+	return sets
+}
+
+// setFlags повторяемый флаг -set путь=значение
+type setFlags []string
+
+func (s *setFlags) String() string { return strings.Join(*s, ", ") }
+
+func (s *setFlags) Set(val string) error {
+	*s = append(*s, val)
+	return nil
+}
+
+// parseArgs разбираем флаги, стоящие как до, так и после имени файла
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	var files []string
+	for {
+		fs.Parse(args) // при ошибке ExitOnError завершает программу
+		if fs.NArg() == 0 {
+			return files
+		}
+		files = append(files, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// flagSet флаг name задан в командной строке
+func flagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// findCommand подкоманда по имени
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// usage справка по командам и флагам
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Используйте: %s [команда] [флаги] <Имя файла>\n\nКоманды:\n", os.Args[0])
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-9s %s\n", c.name, c.usage)
 	}
+	fmt.Fprint(w, `
+Флаги:
+  -config <файл>     файл конфига (по умолчанию config.json)
+  -sheet <лист>      лист чтения (для write - лист вывода, для pivot - лист исходных данных)
+  -start-row <N>     строка начала данных
+  -out <файл>        файл результата (по умолчанию <имя>_out.xlsx)
+  -overwrite         разрешить запись результата во входной файл
+  -log-level <lvl>   уровень логирования: debug, info, warn, error
+  -log-type <type>   логгер: zap, logrus
+  -rows <N>          сколько первых строк показать (inspect, по умолчанию 10)
+  -set <путь=знач>   задать любой параметр конфига, например -set output.backup=true
+                     или -set read_file_settings.fields.6.format=0.00 (можно несколько раз)
+  -version           показать версию и выйти
 
+Флаги можно писать с одним или двумя дефисами (-out, --out), до или после имени файла.
+Файл конфига - JSON, YAML (.yaml, .yml) или TOML (.toml). Порядок применения настроек, каждый
+следующий заменяет предыдущий: env-default, файл конфига, переменные окружения, -set, отдельные флаги.
+`)
 }
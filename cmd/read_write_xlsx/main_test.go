@@ -0,0 +1,28 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOptionsSheet(t *testing.T) {
+	o := &options{sheet: "Данные", startRow: 3}
+	for _, name := range []string{"run", "read", "convert", "validate", "write", "pivot", "inspect"} {
+		cmd, ok := findCommand(name)
+		if !ok {
+			t.Fatalf("команда %v не найдена", name)
+		}
+		want := []string{"read_file_settings.start_row=3"}
+		if cmd.readSheet {
+			want = append([]string{"read_file_settings.sheet_name=Данные"}, want...)
+		}
+		if got := o.sets(cmd); !reflect.DeepEqual(got, want) {
+			t.Errorf("%v: sets = %q, ожидалось %q", name, got, want)
+		}
+	}
+	for name, read := range map[string]bool{"read": true, "write": false, "pivot": false} {
+		if cmd, _ := findCommand(name); cmd.readSheet != read {
+			t.Errorf("%v: -sheet - лист чтения = %v, ожидалось %v", name, cmd.readSheet, read)
+		}
+	}
+}
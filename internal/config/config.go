@@ -2,8 +2,12 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"read_write_xlsx/pkg/sqldb"
 	"read_write_xlsx/pkg/xlsx"
+	"reflect"
 )
 
 var (
@@ -18,28 +22,100 @@ func ShowVersion() string {
 
 // Config ...
 type Config struct {
-	LogLevel string `json:"log_level"`
+	LogLevel string `json:"log_level" env:"RWX_LOG_LEVEL"`
+	LogType  string `json:"log_type,omitempty" env:"RWX_LOG_TYPE"` // логгер: zap (по умолчанию), logrus
+
+	Validate    bool   `json:"validate,omitempty" env:"RWX_VALIDATE"`         // проверять все строки и собирать ошибки, не прерывая обработку
+	ErrorsFile  string `json:"errors_file,omitempty" env:"RWX_ERRORS_FILE"`   // файл отчёта об ошибках (*.csv или Excel-файл)
+	ErrorsSheet string `json:"errors_sheet,omitempty" env:"RWX_ERRORS_SHEET"` // лист отчёта об ошибках в Excel-файле
 
 	ReadFileSettings struct {
-		SheetName string                  `json:"sheet_name,omitempty"`
-		StartRow  int                     `json:"start_row,omitempty" env:"START_ROW" env-default:"2"`
-		Fields    map[int]xlsx.FieldExcel `json:"fields"`
+		SheetName    string                  `json:"sheet_name,omitempty" env:"RWX_SHEET_NAME"`
+		StartRow     int                     `json:"start_row,omitempty" env:"RWX_START_ROW,START_ROW" env-default:"2"`
+		MatchHeaders bool                    `json:"match_headers,omitempty" env:"RWX_MATCH_HEADERS"` // искать колонки по заголовкам полей (header, aliases)
+		HeaderRow    int                     `json:"header_row,omitempty" env:"RWX_HEADER_ROW"`       // номер строки заголовка (0 - искать автоматически)
+		Format       string                  `json:"format,omitempty" env:"RWX_FORMAT"`               // формат файла (xlsx, csv, tsv, json, ndjson), по умолчанию - по расширению
+		Value        string                  `json:"value,omitempty" env:"RWX_VALUE"`                 // что читать из ячеек: raw (по умолчанию), formatted, both
+		CSV          xlsx.CSVOptions         `json:"csv,omitempty"`                                   // настройки CSV/TSV
+		Fields       map[int]xlsx.FieldExcel `json:"fields"`
 	} `json:"read_file_settings"`
 
+	ReadJobs []xlsx.ReadJob `json:"read_jobs,omitempty"` // чтение нескольких листов Excel-файла (вместо read_file_settings)
+
 	WriteFileSettings map[int]xlsx.FieldExcel `json:"write_file_settings"`
+
+	Pivots    []xlsx.PivotSettings   `json:"pivots"`              // сводные таблицы (source_sheet по умолчанию - лист вывода)
+	Summaries []xlsx.SummarySettings `json:"summaries,omitempty"` // сводки, вычисляемые программой по прочитанным записям
+
+	DBExport sqldb.ExportSettings `json:"db_export,omitempty"` // выгрузка прочитанных записей в таблицу БД
+	DBImport sqldb.QuerySettings  `json:"db_import,omitempty"` // вывод результата запроса к БД вместо чтения файла
+
+	Output struct {
+		File           string           `json:"file,omitempty" env:"RWX_OUTPUT_FILE"`                // файл результата (по умолчанию <имя>_out.xlsx)
+		CopySheets     []string         `json:"copy_sheets,omitempty"`                               // листы входного файла, копируемые в файл результата
+		OverwriteInput bool             `json:"overwrite_input,omitempty" env:"RWX_OVERWRITE_INPUT"` // разрешить запись результата во входной файл
+		Backup         bool             `json:"backup,omitempty" env:"RWX_BACKUP"`                   // сохранять предыдущую версию файла результата как .bak
+		JSON           xlsx.JSONOptions `json:"json,omitempty"`                                      // настройки записи JSON/NDJSON
+	} `json:"output"`
+}
+
+// String конфиг для вывода в лог: строки подключения к БД (в них логины и пароли) скрыты
+func (c Config) String() string {
+	type plain Config // без метода String
+	p := plain(c)
+	p.DBExport.DSN = redactDSN(p.DBExport.DSN)
+	p.DBImport.DSN = redactDSN(p.DBImport.DSN)
+	return fmt.Sprintf("%v", p)
+}
+
+// redactDSN строка подключения для лога
+func redactDSN(dsn string) string {
+	if dsn == "" {
+		return ""
+	}
+	return "***"
 }
 
 // LoadConfig reads configuration from file or environment variables.
-func LoadConfig(path string) (config Config, err error) {
-	bytes, err := os.ReadFile(path)
-	if err != nil {
-		return Config{}, err
+// Формат файла - по расширению: .json, .yaml/.yml, .toml. Порядок применения настроек (каждый следующий
+// заменяет предыдущий): env-default, файл конфига, переменные окружения (тег env), sets - "путь=значение"
+// из командной строки (см. Config.Set).
+// Конфиг проверяется целиком: неизвестные и повторяющиеся параметры, описания полей.
+// Пустой path - конфиг без файла: значения по умолчанию, переменные окружения и sets.
+// Все найденные проблемы возвращаются одной ошибкой *Error, значения полей по умолчанию заполняются сразу.
+func LoadConfig(path string, sets ...string) (config Config, err error) {
+	var (
+		c        Config
+		problems []string
+	)
+	if path != "" { // без файла - значения по умолчанию
+		bytes, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, err
+		}
+		if bytes, err = toJSON(bytes, filepath.Ext(path)); err != nil {
+			return Config{}, fmt.Errorf("конфиг %v: %w", path, err)
+		}
+
+		if problems, err = checkJSON(bytes); err != nil {
+			return Config{}, fmt.Errorf("конфиг %v: %w", path, err)
+		}
+
+		if err = json.Unmarshal(bytes, &c); err != nil {
+			return Config{}, fmt.Errorf("конфиг %v: %w", path, err)
+		}
+	}
+
+	problems = append(problems, applyEnv(reflect.ValueOf(&c).Elem())...)
+	for _, set := range sets {
+		if err := c.Set(set); err != nil {
+			problems = append(problems, err.Error())
+		}
 	}
 
-	var c Config
-	err = json.Unmarshal(bytes, &c)
-	if err != nil {
-		return Config{}, err
+	problems = append(problems, c.Check()...)
+	if len(problems) > 0 {
+		return Config{}, &Error{Path: path, Problems: problems}
 	}
 
 	return c, nil
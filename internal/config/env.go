@@ -0,0 +1,164 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// applyEnv значения из переменных окружения (тег env) и значения по умолчанию (тег env-default)
+// для простых параметров конфига. env-default применяется, если параметр не задан ни в файле, ни в окружении.
+// В теге env можно перечислить несколько имён через запятую, берётся первая заданная переменная
+// (имена без префикса RWX_ оставлены только для совместимости).
+func applyEnv(v reflect.Value) []string {
+	var problems []string
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f, fv := t.Field(i), v.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if f.Type.Kind() == reflect.Struct {
+			problems = append(problems, applyEnv(fv)...)
+			continue
+		}
+		name, def := f.Tag.Get("env"), f.Tag.Get("env-default")
+		if name == "" && def == "" {
+			continue
+		}
+		if name, val, ok := lookupEnv(name); ok {
+			if err := setScalar(fv, val); err != nil {
+				problems = append(problems, fmt.Sprintf("переменная окружения %s: %v", name, err))
+			}
+			continue
+		}
+		if def != "" && fv.IsZero() {
+			if err := setScalar(fv, def); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: env-default %q: %v", f.Name, def, err))
+			}
+		}
+	}
+	return problems
+}
+
+// lookupEnv первая заданная переменная окружения из списка names через запятую
+func lookupEnv(names string) (string, string, bool) {
+	if names == "" {
+		return "", "", false
+	}
+	for _, name := range strings.Split(names, ",") {
+		if val, ok := os.LookupEnv(strings.TrimSpace(name)); ok {
+			return name, val, true
+		}
+	}
+	return "", "", false
+}
+
+// setScalar значение простого параметра из текста
+func setScalar(v reflect.Value, val string) error {
+	val = strings.TrimSpace(val)
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(val)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("ожидается true или false: %q", val)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return fmt.Errorf("ожидается целое число: %q", val)
+		}
+		v.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return fmt.Errorf("ожидается число: %q", val)
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("тип %v не поддерживается", v.Type())
+	}
+	return nil
+}
+
+// Set задаём параметр конфига по пути из имён JSON: "read_file_settings.start_row=3",
+// "read_file_settings.fields.6.format=0.00", "output.copy_sheets=[\"Платежи\"]".
+// Строки задаются как есть, остальные значения - в JSON.
+func (c *Config) Set(assignment string) error {
+	path, val, ok := strings.Cut(assignment, "=")
+	if !ok || strings.TrimSpace(path) == "" {
+		return fmt.Errorf("%q: ожидается путь=значение", assignment)
+	}
+	if err := setPath(reflect.ValueOf(c).Elem(), strings.Split(strings.TrimSpace(path), "."), val); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// setPath задаём значение по пути в структурах, map и срезах
+func setPath(v reflect.Value, path []string, val string) error {
+	if len(path) == 0 {
+		if v.Kind() == reflect.String {
+			v.SetString(val)
+			return nil
+		}
+		if v.Kind() == reflect.Bool || v.Kind() >= reflect.Int && v.Kind() <= reflect.Float64 {
+			return setScalar(v, val)
+		}
+		ptr := reflect.New(v.Type())
+		if err := json.Unmarshal([]byte(val), ptr.Interface()); err != nil {
+			return err
+		}
+		v.Set(ptr.Elem())
+		return nil
+	}
+
+	key := path[0]
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setPath(v.Elem(), path, val)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+			if t.Field(i).IsExported() && name != "-" && strings.EqualFold(name, key) {
+				return setPath(v.Field(i), path[1:], val)
+			}
+		}
+		return fmt.Errorf("неизвестный параметр %q", key)
+	case reflect.Map:
+		mk := reflect.New(v.Type().Key()).Elem()
+		if err := setScalar(mk, key); err != nil {
+			return fmt.Errorf("ключ %q: %w", key, err)
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		// значения map не адресуемы: меняем копию и записываем обратно
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if cur := v.MapIndex(mk); cur.IsValid() {
+			elem.Set(cur)
+		}
+		if err := setPath(elem, path[1:], val); err != nil {
+			return err
+		}
+		v.SetMapIndex(mk, elem)
+		return nil
+	case reflect.Slice:
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= v.Len() {
+			return fmt.Errorf("нет элемента %q (элементов: %d)", key, v.Len())
+		}
+		return setPath(v.Index(i), path[1:], val)
+	}
+	return fmt.Errorf("параметр %q не содержит вложенных параметров", key)
+}
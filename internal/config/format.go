@@ -0,0 +1,63 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// toJSON текст конфига в формате YAML или TOML переводим в JSON: проверка и разбор
+// выполняются по тегам json, как для JSON-конфига
+func toJSON(data []byte, ext string) ([]byte, error) {
+	var raw interface{}
+	switch strings.ToLower(ext) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("yaml: %w", err)
+		}
+	case ".toml":
+		var doc map[string]interface{}
+		if _, err := toml.Decode(string(data), &doc); err != nil {
+			return nil, fmt.Errorf("toml: %w", err)
+		}
+		raw = doc
+	default:
+		return data, nil
+	}
+	if raw == nil {
+		raw = map[string]interface{}{}
+	}
+	return json.Marshal(stringKeys(raw))
+}
+
+// stringKeys ключи map YAML (числа - номера колонок) приводим к строкам для JSON
+func stringKeys(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for k, val := range x {
+			x[k] = stringKeys(val)
+		}
+		return x
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(x))
+		for k, val := range x {
+			m[fmt.Sprint(k)] = stringKeys(val)
+		}
+		return m
+	case []interface{}:
+		for i, val := range x {
+			x[i] = stringKeys(val)
+		}
+		return x
+	case []map[string]interface{}: // массив таблиц TOML
+		list := make([]interface{}, len(x))
+		for i, val := range x {
+			list[i] = stringKeys(val)
+		}
+		return list
+	}
+	return v
+}
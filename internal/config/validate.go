@@ -0,0 +1,241 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"read_write_xlsx/pkg/xlsx"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Error ошибки конфига: все найденные проблемы сразу
+type Error struct {
+	Path     string   // файл конфига
+	Problems []string // описания проблем: "путь: причина"
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("ошибки в конфиге %v:\n\t%s", e.Path, strings.Join(e.Problems, "\n\t"))
+}
+
+// Check проверка конфига и заполнение значений полей по умолчанию (xlsx.FieldsDefaults)
+func (c *Config) Check() []string {
+	var problems []string
+	checkFields := func(path string, fields map[int]xlsx.FieldExcel) {
+		for _, err := range xlsx.CheckFields(fields) {
+			problems = append(problems, fmt.Sprintf("%s: %v", path, err))
+		}
+		xlsx.FieldsDefaults(fields)
+	}
+
+	if c.ReadFileSettings.StartRow < 0 {
+		problems = append(problems, "read_file_settings.start_row: отрицательный номер строки")
+	}
+	if c.ReadFileSettings.HeaderRow < 0 {
+		problems = append(problems, "read_file_settings.header_row: отрицательный номер строки")
+	}
+	if !xlsx.IsValueMode(c.ReadFileSettings.Value) {
+		problems = append(problems, fmt.Sprintf("read_file_settings.value: неизвестный режим %q (допустимы: raw, formatted, both)", c.ReadFileSettings.Value))
+	}
+	checkFields("read_file_settings.fields", c.ReadFileSettings.Fields)
+	for i, job := range c.ReadJobs {
+		path := fmt.Sprintf("read_jobs[%d]", i)
+		if job.StartRow < 0 {
+			problems = append(problems, path+".start_row: отрицательный номер строки")
+		}
+		if !xlsx.IsValueMode(job.Value) {
+			problems = append(problems, fmt.Sprintf("%s.value: неизвестный режим %q (допустимы: raw, formatted, both)", path, job.Value))
+		}
+		checkFields(path+".fields", job.Fields)
+	}
+	checkFields("write_file_settings", c.WriteFileSettings)
+	return problems
+}
+
+// checkJSON проверка текста конфига: повторяющиеся ключи объектов и неизвестные параметры
+func checkJSON(data []byte) ([]string, error) {
+	problems, err := duplicateKeys(data)
+	if err != nil {
+		return nil, err
+	}
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return append(problems, unknownFields(raw, reflect.TypeOf(Config{}), "")...), nil
+}
+
+// jsonFrame уровень вложенности JSON при разборе по токенам
+type jsonFrame struct {
+	object  bool
+	keys    map[string]string // нормализованный ключ -> ключ в файле
+	key     string            // текущий ключ объекта
+	index   int               // текущий индекс массива
+	wantKey bool
+}
+
+// duplicateKeys повторяющиеся ключи объектов. Числовые ключи сравниваются как числа ("2" и "02" - одна колонка).
+func duplicateKeys(data []byte) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var (
+		stack    []*jsonFrame
+		problems []string
+	)
+	path := func() string {
+		var sb strings.Builder
+		for _, f := range stack {
+			if f.object {
+				if sb.Len() > 0 {
+					sb.WriteByte('.')
+				}
+				sb.WriteString(f.key)
+			} else {
+				fmt.Fprintf(&sb, "[%d]", f.index)
+			}
+		}
+		return sb.String()
+	}
+	valueDone := func() {
+		if len(stack) == 0 {
+			return
+		}
+		if top := stack[len(stack)-1]; top.object {
+			top.wantKey = true
+		} else {
+			top.index++
+		}
+	}
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return problems, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case json.Delim:
+			switch t {
+			case '{':
+				stack = append(stack, &jsonFrame{object: true, keys: make(map[string]string), wantKey: true})
+			case '[':
+				stack = append(stack, &jsonFrame{})
+			default:
+				stack = stack[:len(stack)-1]
+				valueDone()
+			}
+			continue
+		case string:
+			if top := stack[len(stack)-1]; top.object && top.wantKey {
+				norm := t
+				if n, err := strconv.Atoi(strings.TrimSpace(t)); err == nil {
+					norm = strconv.Itoa(n)
+				}
+				top.key = t
+				top.wantKey = false
+				if prev, ok := top.keys[norm]; ok {
+					problems = append(problems, fmt.Sprintf("%s: повторяется ключ (уже задан как %q)", path(), prev))
+				} else {
+					top.keys[norm] = t
+				}
+				continue
+			}
+		}
+		valueDone()
+	}
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// unknownFields параметры JSON, которых нет в описании типа t (имена сравниваются без учёта регистра, как в encoding/json)
+func unknownFields(raw interface{}, t reflect.Type, path string) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	var problems []string
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		fields := make(map[string]reflect.StructField, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name := strings.Split(f.Tag.Get("json"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			fields[strings.ToLower(name)] = f
+		}
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sortStrings(keys)
+		for _, key := range keys {
+			f, ok := fields[strings.ToLower(key)]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s: неизвестный параметр", join(key)))
+				continue
+			}
+			problems = append(problems, unknownFields(obj[key], f.Type, join(key))...)
+		}
+	case reflect.Map:
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sortStrings(keys)
+		for _, key := range keys {
+			problems = append(problems, unknownFields(obj[key], t.Elem(), join(key))...)
+		}
+	case reflect.Slice, reflect.Array:
+		list, ok := raw.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i, item := range list {
+			problems = append(problems, unknownFields(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return problems
+}
+
+// sortStrings ключи по порядку: числовые - как числа
+func sortStrings(keys []string) {
+	sort.Slice(keys, func(i, j int) bool {
+		a, errA := strconv.Atoi(keys[i])
+		b, errB := strconv.Atoi(keys[j])
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return keys[i] < keys[j]
+	})
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"read_write_xlsx/pkg/xlsx"
+	"strings"
+)
+
+// Read читаем записи файла и выгружаем их в файл результата (по умолчанию <имя>.ndjson).
+// Формат результата - по расширению: json, ndjson, csv, tsv или Excel.
+func (app *App) Read(filename string) error {
+	out := app.cfg.Output.File
+	if out == "" {
+		out = strings.TrimSuffix(filename, filepath.Ext(filename)) + ".ndjson"
+	}
+	return app.convert(filename, out)
+}
+
+// Convert переводим записи файла в другой формат, файл результата обязателен (output.file, -out)
+func (app *App) Convert(filename string) error {
+	if app.cfg.Output.File == "" {
+		return fmt.Errorf("не задан файл результата (-out)")
+	}
+	return app.convert(filename, app.cfg.Output.File)
+}
+
+// convert записи файла filename по полям чтения выводим в файл out
+func (app *App) convert(filename, out string) error {
+	if sameFile(filename, out) {
+		return fmt.Errorf("файл результата совпадает с входным файлом %v", filename)
+	}
+	app.log.Infof("Читаем файл %v, результат выводим в файл %v", filename, out)
+
+	in, err := app.openInput(filename)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		defer in.Close()
+	}
+	data, errs, err := app.readData(filename, in, app.cfg.Validate)
+	if err != nil {
+		return err
+	}
+
+	fileOut := xlsx.NewFieldsExcel(sheetNameData, app.dataFields(), app.log)
+	fileOut.SetJSONOptions(app.cfg.Output.JSON)
+	fileOut.SetSaveOptions(app.saveOptions())
+	if err := fileOut.DataToExcel(out, 1, data); err != nil {
+		return err
+	}
+	app.log.Infof("Записано: %v", len(data))
+	if err := app.reportErrors(errs); err != nil {
+		return err
+	}
+	app.log.Info("Выполнено")
+	return nil
+}
+
+// Write записываем данные файла filename (JSON, NDJSON, CSV) по write_file_settings на лист sheet
+// файла результата, со сводными таблицами и сводками из конфига
+func (app *App) Write(filename, sheet string) error {
+	if sheet == "" {
+		sheet = sheetNameData
+	}
+	outFile, err := app.outputFile(filename)
+	if err != nil {
+		return err
+	}
+	app.log.Infof("Записываем данные файла %v на лист %v файла %v", filename, sheet, outFile)
+
+	fileIn := xlsx.NewFieldsExcel("", app.cfg.WriteFileSettings, app.log)
+	fileIn.SetCSVOptions(app.cfg.ReadFileSettings.CSV)
+	var (
+		data []map[string]interface{}
+		errs xlsx.CellErrors
+	)
+	if app.cfg.Validate {
+		data, errs, err = fileIn.ExcelToDataValidate(filename, app.cfg.ReadFileSettings.StartRow)
+	} else {
+		data, err = fileIn.ExcelToData(filename, app.cfg.ReadFileSettings.StartRow)
+	}
+	if err != nil {
+		return err
+	}
+
+	var out *xlsx.Workbook
+	if isExcelFile(outFile) {
+		if out, err = xlsx.OpenOrNewWorkbook(outFile); err != nil {
+			return err
+		}
+		defer out.Close()
+		out.SetSaveOptions(app.saveOptions())
+	}
+	writeErrs, err := app.writeData(out, outFile, sheet, data)
+	if err != nil {
+		return err
+	}
+	if err := app.reportErrors(append(errs, writeErrs...)); err != nil {
+		return err
+	}
+	app.log.Info("Выполнено")
+	return nil
+}
+
+// Pivot создаём сводные таблицы из конфига в Excel-файле, sheet - лист исходных данных по умолчанию
+func (app *App) Pivot(filename, sheet string) error {
+	if sheet == "" {
+		sheet = sheetNameData
+	}
+	if len(app.cfg.Pivots) == 0 {
+		return fmt.Errorf("в конфиге не заданы сводные таблицы (pivots)")
+	}
+	outFile, err := app.outputFile(filename)
+	if err != nil {
+		return err
+	}
+	app.log.Infof("Создаём сводные таблицы по файлу %v, результат выводим в файл %v", filename, outFile)
+
+	wb, err := xlsx.OpenWorkbook(filename)
+	if err != nil {
+		return err
+	}
+	defer wb.Close()
+	wb.SetSaveOptions(app.saveOptions())
+	if err := app.createPivots(wb, sheet); err != nil {
+		return err
+	}
+	if sameFile(filename, outFile) {
+		err = wb.Save()
+	} else {
+		err = wb.SaveAs(outFile)
+	}
+	if err != nil {
+		return err
+	}
+	app.log.Info("Выполнено")
+	return nil
+}
+
+// Validate проверяем все записи файла по правилам полей, ошибки сохраняются в errors_file.
+// Возвращает ошибку, если в данных найдены ошибки.
+func (app *App) Validate(filename string) error {
+	app.log.Infof("Проверяем файл %v", filename)
+	in, err := app.openInput(filename)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		defer in.Close()
+	}
+	data, errs, err := app.readData(filename, in, true)
+	if err != nil {
+		return err
+	}
+	app.log.Infof("Прочитано записей: %v", len(data))
+	if err := app.reportErrors(errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("найдены ошибки в данных: %v", len(errs))
+	}
+	app.log.Info("Ошибок не найдено")
+	return nil
+}
+
+// Inspect выводим описание листов Excel-файла (все или sheet): размеры, первые rows строк,
+// строку заголовка, типы колонок и описание полей для конфига
+func (app *App) Inspect(filename, sheet string, rows int) error {
+	opts := xlsx.InspectOptions{Preview: rows}
+	if sheet != "" {
+		opts.Sheets = []string{sheet}
+	}
+	sheets, err := xlsx.Inspect(filename, opts)
+	if err != nil {
+		return err
+	}
+	for _, info := range sheets {
+		if err := info.WriteText(os.Stdout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dataFields поля прочитанных записей по порядку (read_jobs или read_file_settings) для вывода
+func (app *App) dataFields() map[int]xlsx.FieldExcel {
+	if len(app.cfg.ReadJobs) > 0 {
+		return jobsFields(app.cfg.ReadJobs)
+	}
+	return jobsFields([]xlsx.ReadJob{{Fields: app.cfg.ReadFileSettings.Fields, Value: app.cfg.ReadFileSettings.Value}})
+}
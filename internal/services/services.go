@@ -1,14 +1,21 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"read_write_xlsx/internal/config"
 	"read_write_xlsx/internal/glogger"
+	"read_write_xlsx/pkg/sqldb"
 	"read_write_xlsx/pkg/xlsx"
-
-	"github.com/xuri/excelize/v2"
+	"sort"
+	"strings"
 )
 
+// sheetNameData лист вывода записей по умолчанию
+const sheetNameData = "Вывод"
+
 // App ...
 type App struct {
 	cfg config.Config
@@ -25,54 +32,384 @@ func (app *App) Run(filename string) error {
 
 	app.log.Infof("Обрабатываем файл %v", filename)
 
+	outFile, err := app.outputFile(filename)
+	if err != nil {
+		return err
+	}
+
+	// Excel-файлы открываем один раз: чтение, запись и свод выполняются в одной книге
+	in, err := app.openInput(filename)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		defer in.Close()
+	}
+	var out *xlsx.Workbook
+	if isExcelFile(outFile) {
+		if sameFile(filename, outFile) && in != nil {
+			out = in
+		} else {
+			if out, err = xlsx.OpenOrNewWorkbook(outFile); err != nil {
+				return err
+			}
+			defer out.Close()
+			out.SetSaveOptions(app.saveOptions())
+		}
+	}
+
+	data, errs, err := app.readData(filename, in, app.cfg.Validate)
+	if err != nil {
+		return err
+	}
+
+	if app.cfg.DBExport.Table != "" {
+		if err := app.exportDB(data); err != nil {
+			return err
+		}
+	}
+
+	app.log.Infof("Результат выводим в файл %v", outFile)
+
+	if len(app.cfg.Output.CopySheets) > 0 && out != in {
+		if in == nil || out == nil {
+			app.log.Warn("Копирование листов возможно только между Excel-файлами, пропускаем")
+		} else {
+			app.log.Debugf("Копируем листы %v", app.cfg.Output.CopySheets)
+			if err := out.CopySheets(in, app.cfg.Output.CopySheets); err != nil {
+				return err
+			}
+		}
+	}
+
+	writeErrs, err := app.writeData(out, outFile, sheetNameData, data)
+	if err != nil {
+		return err
+	}
+	errs = append(errs, writeErrs...)
+
+	if err := app.reportErrors(errs); err != nil {
+		return err
+	}
+
+	app.log.Info("Выполнено")
+
+	return nil
+}
+
+// fieldsRead описание полей чтения из read_file_settings
+func (app *App) fieldsRead() xlsx.FieldsExcel {
 	fileExcelRead := xlsx.NewFieldsExcel(app.cfg.ReadFileSettings.SheetName, app.cfg.ReadFileSettings.Fields, app.log)
+	fileExcelRead.SetFormat(app.cfg.ReadFileSettings.Format)
+	fileExcelRead.SetCSVOptions(app.cfg.ReadFileSettings.CSV)
+	fileExcelRead.SetValueMode(app.cfg.ReadFileSettings.Value)
+	if app.cfg.ReadFileSettings.MatchHeaders {
+		fileExcelRead.UseHeaders(app.cfg.ReadFileSettings.HeaderRow)
+	}
 	app.log.Debugf("fileExcelRead: %v", fileExcelRead)
-	data, err := fileExcelRead.ExcelToData(filename, app.cfg.ReadFileSettings.StartRow)
+	return fileExcelRead
+}
+
+// openInput открываем входной Excel-файл как книгу, для остальных форматов - nil
+func (app *App) openInput(filename string) (*xlsx.Workbook, error) {
+	if isExcelFile(filename) && (app.cfg.ReadFileSettings.Format == "" || app.cfg.ReadFileSettings.Format == xlsx.FormatXLSX) {
+		wb, err := xlsx.OpenWorkbook(filename)
+		if err != nil {
+			return nil, err
+		}
+		wb.SetSaveOptions(app.saveOptions())
+		return wb, nil
+	}
+	return nil, nil
+}
+
+// saveOptions настройки сохранения файлов результата
+func (app *App) saveOptions() xlsx.SaveOptions {
+	return xlsx.SaveOptions{Backup: app.cfg.Output.Backup}
+}
+
+// readData читаем записи файла (книги in, если она открыта) по read_jobs или read_file_settings,
+// validate - собирать ошибки ячеек вместо прерывания чтения
+func (app *App) readData(filename string, in *xlsx.Workbook, validate bool) ([]map[string]interface{}, xlsx.CellErrors, error) {
+	fileExcelRead := app.fieldsRead()
+	startRow := app.cfg.ReadFileSettings.StartRow
+
+	var (
+		data []map[string]interface{}
+		errs xlsx.CellErrors
+		err  error
+	)
+	switch {
+	case len(app.cfg.ReadJobs) > 0:
+		data, errs, err = app.readJobs(in, validate)
+	case in != nil && validate:
+		data, errs, err = fileExcelRead.SheetToDataValidate(in, startRow)
+	case in != nil:
+		data, err = fileExcelRead.SheetToData(in, startRow)
+	case validate:
+		data, errs, err = fileExcelRead.ExcelToDataValidate(filename, startRow)
+	default:
+		data, err = fileExcelRead.ExcelToData(filename, startRow)
+	}
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	// Покажем несколько записей для примера
-	for i := 0; i < 5; i++ {
+	for i := 0; i < 5 && i < len(data); i++ {
 		app.log.Debug(i, data[i])
 	}
+	return data, errs, nil
+}
 
-	// Создадим в файле новый лист
-	sheetNameData := "Вывод"
-	fileExcelWrite := xlsx.NewFieldsExcel(sheetNameData, app.cfg.WriteFileSettings, app.log)
+// writeData выводим записи по write_file_settings на лист sheet книги out со сводными таблицами и сводками,
+// если out == nil - в файл outFile (CSV/TSV, JSON/NDJSON)
+func (app *App) writeData(out *xlsx.Workbook, outFile, sheet string, data []map[string]interface{}) (xlsx.CellErrors, error) {
+	fileExcelWrite := xlsx.NewFieldsExcel(sheet, app.cfg.WriteFileSettings, app.log)
+	fileExcelWrite.SetJSONOptions(app.cfg.Output.JSON)
+	fileExcelWrite.SetSaveOptions(app.saveOptions())
 	app.log.Debugf("fileExcelWrite: %v", fileExcelWrite)
 
-	if err := fileExcelWrite.DataToExcel(filename, 1, data); err != nil {
+	if out == nil { // CSV/TSV, JSON/NDJSON
+		if len(app.cfg.Pivots) > 0 || len(app.cfg.Summaries) > 0 {
+			app.log.Warnf("Сводные таблицы и сводки строятся только в Excel-файле, для %v пропускаем", outFile)
+		}
+		if app.cfg.Validate {
+			return fileExcelWrite.DataToExcelValidate(outFile, 1, data)
+		}
+		return nil, fileExcelWrite.DataToExcel(outFile, 1, data)
+	}
+
+	var errs xlsx.CellErrors
+	if app.cfg.Validate {
+		writeErrs, err := fileExcelWrite.DataToSheetValidate(out, 1, data)
+		if err != nil {
+			return nil, err
+		}
+		errs = writeErrs
+	} else if err := fileExcelWrite.DataToSheet(out, 1, data); err != nil {
+		return nil, err
+	}
+	if err := app.createPivots(out, sheet); err != nil {
+		return nil, err
+	}
+	for _, summary := range app.cfg.Summaries {
+		app.log.Debugf("Создадим лист сводки %v", summary.Sheet)
+		if err := out.WriteSummary(summary, data); err != nil {
+			return nil, err
+		}
+	}
+	if err := out.Save(); err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
+
+// QueryMode в конфиге задан запрос db_import: файл - результат запроса
+func (app *App) QueryMode() bool {
+	return app.cfg.DBImport.Query != ""
+}
+
+// RunQuery выполняем запрос db_import и выводим результат в файл outFile
+func (app *App) RunQuery(outFile string) error {
+	app.log.Infof("Выполняем запрос, результат выводим в файл %v", outFile)
+
+	db, err := app.cfg.DBImport.Open()
+	if err != nil {
 		return err
 	}
+	defer db.Close()
+
+	sheet := app.cfg.DBImport.Sheet
+	if sheet == "" {
+		sheet = sheetNameData
+	}
+	ctx := context.Background()
+	if !isExcelFile(outFile) {
+		return sqldb.QueryToExcel(ctx, db, app.cfg.DBImport.Query, outFile, sheet, app.cfg.WriteFileSettings, app.saveOptions(), app.log)
+	}
 
-	app.log.Debug("Создадим в файле новый лист для сводной информации")
-	sheetNamePivot := "Свод по платежам"
-	letterLastColumn := "E"
-	dataRange := fmt.Sprintf("%s!$A$%d:$%s$%d", sheetNameData, 1, letterLastColumn, len(data)+1)
-	pivotTableRange := fmt.Sprintf("%s!$B$5:$E$20", sheetNamePivot)
-
-	app.log.Debugf("dataRange: %v, pivotTableRange: %v", dataRange, pivotTableRange)
-
-	if err := fileExcelWrite.CreatePivotTableFile(filename, sheetNamePivot,
-		dataRange,
-		pivotTableRange,
-		[]excelize.PivotTableField{ //PivotTableRows
-			{Data: "Дата платежа", Name: "Дата платежа"}, {Data: "Лицевой счет"},
-		},
-		[]excelize.PivotTableField{ //PivotTableFilter
-		},
-		[]excelize.PivotTableField{ //PivotTableColumns
-		},
-		[]excelize.PivotTableField{ //PivotTableData
-			{Data: "Сумма платежа", Name: "Сумма платежа", Subtotal: "Sum"},
-			{Data: "Лицевой счет", Name: "Количество", Subtotal: "Count"},
-		},
-	); err != nil {
+	q, err := sqldb.Query(ctx, db, app.cfg.DBImport.Query)
+	if err != nil {
+		return err
+	}
+	defer q.Close()
+
+	out, err := xlsx.OpenOrNewWorkbook(outFile)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	out.SetSaveOptions(app.saveOptions())
+
+	fileExcelWrite := xlsx.NewFieldsExcel(sheet, q.Fields(app.cfg.WriteFileSettings), app.log)
+	app.log.Debugf("fileExcelWrite: %v", fileExcelWrite)
+	if err := fileExcelWrite.StreamToSheet(out, 1, q.Next); err != nil {
+		return err
+	}
+	if err := app.createPivots(out, sheet); err != nil {
+		return err
+	}
+	if err := out.Save(); err != nil {
 		return err
 	}
 
 	app.log.Info("Выполнено")
+	return nil
+}
 
+// readJobs читаем листы книги по заданиям read_jobs, записи всех листов объединяются по порядку листов
+func (app *App) readJobs(in *xlsx.Workbook, validate bool) ([]map[string]interface{}, xlsx.CellErrors, error) {
+	if in == nil {
+		return nil, nil, fmt.Errorf("задания read_jobs поддерживаются только для Excel-файлов")
+	}
+	var (
+		bySheet map[string][]map[string]interface{}
+		sheets  []string
+		errs    xlsx.CellErrors
+		err     error
+	)
+	if validate {
+		bySheet, sheets, errs, err = xlsx.ReadJobsValidate(in, app.cfg.ReadJobs, app.log)
+	} else {
+		bySheet, sheets, err = xlsx.ReadJobs(in, app.cfg.ReadJobs, app.log)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	data := make([]map[string]interface{}, 0)
+	for _, sheet := range sheets {
+		app.log.Infof("Лист %v: прочитано %v", sheet, len(bySheet[sheet]))
+		data = append(data, bySheet[sheet]...)
+	}
+	return data, errs, nil
+}
+
+// exportDB выгружаем прочитанные записи в таблицу БД
+func (app *App) exportDB(data []map[string]interface{}) error {
+	exp, err := sqldb.NewExporter(app.cfg.DBExport, app.dataFields())
+	if err != nil {
+		return err
+	}
+	if app.cfg.DBExport.DryRun {
+		app.log.Infof("Выгрузка в БД (dry-run), создание таблицы:\n%v", exp.DDL())
+		app.log.Infof("Запрос вставки (для одной строки):\n%v", exp.Query(1))
+		return nil
+	}
+
+	db, err := exp.Open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	count, err := exp.Export(context.Background(), db, data)
+	if err != nil {
+		return fmt.Errorf("выгрузка в таблицу %v: %w", app.cfg.DBExport.Table, err)
+	}
+	app.log.Infof("Выгружено в таблицу %v: %v", app.cfg.DBExport.Table, count)
 	return nil
 }
+
+// jobsFields поля всех заданий чтения (и поля имени листа) для выгрузки
+func jobsFields(jobs []xlsx.ReadJob) map[int]xlsx.FieldExcel {
+	fields := make(map[int]xlsx.FieldExcel)
+	key := 0
+	for _, job := range jobs {
+		for _, k := range sortedKeys(job.Fields) {
+			key++
+			f := job.Fields[k]
+			fields[key] = f
+			if f.Value == xlsx.ValueBoth || (f.Value == "" && job.Value == xlsx.ValueBoth) { // текст ячейки - следующей колонкой
+				key++
+				fields[key] = xlsx.FieldExcel{Name: f.Name + xlsx.TextSuffix, Header: f.Header + " (текст)", Width: f.Width}
+			}
+		}
+		if job.SheetField != "" {
+			key++
+			fields[key] = xlsx.FieldExcel{Name: job.SheetField}
+		}
+	}
+	return fields
+}
+
+// sortedKeys ключи полей по возрастанию
+func sortedKeys(fields map[int]xlsx.FieldExcel) []int {
+	keys := make([]int, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// createPivots создаём в книге сводные таблицы из конфига по листу данных sheet
+func (app *App) createPivots(wb *xlsx.Workbook, sheet string) error {
+	for _, p := range app.cfg.Pivots {
+		if p.SourceSheet == "" {
+			p.SourceSheet = sheet
+		}
+		app.log.Debugf("Создадим лист сводной таблицы %v по листу %v", p.Sheet, p.SourceSheet)
+		if err := wb.AddPivotTable(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reportErrors выводим ошибки проверки данных и сохраняем отчёт
+func (app *App) reportErrors(errs xlsx.CellErrors) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	app.log.Warnf("Найдены ошибки в данных: %v (строки с ошибками пропущены)", len(errs))
+	for _, e := range errs {
+		app.log.Debug(e.Error())
+	}
+	if app.cfg.ErrorsFile == "" {
+		return nil
+	}
+	if err := errs.Save(app.cfg.ErrorsFile, app.cfg.ErrorsSheet, app.saveOptions()); err != nil {
+		return fmt.Errorf("отчёт об ошибках %v: %w", app.cfg.ErrorsFile, err)
+	}
+	app.log.Infof("Отчёт об ошибках: %v", app.cfg.ErrorsFile)
+	return nil
+}
+
+// outputFile имя файла результата. Запись во входной файл только с разрешения overwrite_input.
+func (app *App) outputFile(filename string) (string, error) {
+	out := app.cfg.Output.File
+	if out == "" {
+		if app.cfg.Output.OverwriteInput && isExcelFile(filename) {
+			return filename, nil
+		}
+		out = strings.TrimSuffix(filename, filepath.Ext(filename)) + "_out.xlsx"
+	}
+	if sameFile(filename, out) {
+		if !app.cfg.Output.OverwriteInput {
+			return "", fmt.Errorf("файл результата совпадает с входным файлом %v (для перезаписи задайте overwrite_input или -overwrite)", filename)
+		}
+		return filename, nil
+	}
+	return out, nil
+}
+
+// sameFile пути указывают на один файл
+func sameFile(a, b string) bool {
+	fa, errA := os.Stat(a)
+	fb, errB := os.Stat(b)
+	if errA == nil && errB == nil {
+		return os.SameFile(fa, fb)
+	}
+	absA, _ := filepath.Abs(a)
+	absB, _ := filepath.Abs(b)
+	return absA == absB
+}
+
+// isExcelFile файл в формате Excel 2007+
+func isExcelFile(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".xlsx", ".xlsm", ".xltx", ".xltm":
+		return true
+	}
+	return false
+}
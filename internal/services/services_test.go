@@ -0,0 +1,116 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"read_write_xlsx/internal/config"
+	"read_write_xlsx/pkg/xlsx"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// testLogger логгер для тестов, запоминает предупреждения
+type testLogger struct {
+	warns []string
+}
+
+func (l *testLogger) Debug(args ...interface{})                 {}
+func (l *testLogger) Debugf(format string, args ...interface{}) {}
+func (l *testLogger) Info(args ...interface{})                  {}
+func (l *testLogger) Infof(format string, args ...interface{})  {}
+func (l *testLogger) Warn(args ...interface{})                  { l.warns = append(l.warns, fmt.Sprint(args...)) }
+func (l *testLogger) Warnf(format string, args ...interface{}) {
+	l.warns = append(l.warns, fmt.Sprintf(format, args...))
+}
+func (l *testLogger) Error(args ...interface{})                 {}
+func (l *testLogger) Errorf(format string, args ...interface{}) {}
+func (l *testLogger) Fatal(args ...interface{})                 {}
+func (l *testLogger) Fatalf(format string, args ...interface{}) {}
+
+// writeInput сохраняем входной Excel-файл с листом "Платежи"
+func writeInput(t *testing.T, dir string) string {
+	t.Helper()
+	f := excelize.NewFile()
+	defer f.Close()
+	if err := f.SetSheetName("Sheet1", "Платежи"); err != nil {
+		t.Fatal(err)
+	}
+	for r, row := range [][]interface{}{{"Ф.И.О.", "Сумма"}, {"Иванов", 10}, {"Петров", 20}} {
+		cell, _ := excelize.CoordinatesToCellName(1, r+1)
+		if err := f.SetSheetRow("Платежи", cell, &row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	filename := filepath.Join(dir, "payments.xlsx")
+	if err := f.SaveAs(filename); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestOutputFile(t *testing.T) {
+	dir := t.TempDir()
+	input := writeInput(t, dir)
+	tests := []struct {
+		name      string
+		file      string
+		overwrite bool
+		want      string
+		wantErr   bool
+	}{
+		{name: "по умолчанию", want: filepath.Join(dir, "payments_out.xlsx")},
+		{name: "output.file", file: filepath.Join(dir, "result.csv"), want: filepath.Join(dir, "result.csv")},
+		{name: "overwrite_input без output.file", overwrite: true, want: input},
+		{name: "входной файл без overwrite_input", file: input, wantErr: true},
+		{name: "входной файл с overwrite_input", file: input, overwrite: true, want: input},
+	}
+	for _, tt := range tests {
+		var cfg config.Config
+		cfg.Output.File = tt.file
+		cfg.Output.OverwriteInput = tt.overwrite
+		got, err := New(cfg, &testLogger{}).outputFile(input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%v: outputFile = %q, %v; ожидалось %q, ошибка %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestRunCSVSkipsSummaries(t *testing.T) {
+	dir := t.TempDir()
+	input := writeInput(t, dir)
+
+	var cfg config.Config
+	cfg.ReadFileSettings.StartRow = 2
+	cfg.ReadFileSettings.Fields = map[int]xlsx.FieldExcel{
+		1: {Name: "fio"},
+		2: {Name: "sum", Type: "int64"},
+	}
+	cfg.WriteFileSettings = map[int]xlsx.FieldExcel{
+		1: {Name: "fio", Header: "Ф.И.О."},
+		2: {Name: "sum", Header: "Сумма"},
+	}
+	cfg.Summaries = []xlsx.SummarySettings{{
+		Sheet:    "Итоги",
+		Measures: []xlsx.SummaryField{{Field: "sum", Func: "sum"}},
+	}}
+	cfg.Output.File = filepath.Join(dir, "result.csv")
+
+	log := &testLogger{}
+	if err := New(cfg, log).Run(input); err != nil {
+		t.Fatal(err)
+	}
+	if len(log.warns) != 1 || !strings.Contains(log.warns[0], "сводки") {
+		t.Errorf("предупреждения: %q, ожидалось предупреждение о пропуске сводок", log.warns)
+	}
+
+	b, err := os.ReadFile(cfg.Output.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Fields(string(b)); len(got) != 3 || got[1] != "Иванов;10" {
+		t.Errorf("result.csv:\n%s", b)
+	}
+}
@@ -0,0 +1,299 @@
+/*
+Package sqldb используется для обмена данными Excel-файла с базами данных через database/sql.
+
+Поддерживаются диалекты SQLite, PostgreSQL и MS SQL. Драйверы не подключаются пакетом,
+их импортирует программа:
+
+	import (
+		_ "github.com/lib/pq"               // postgres
+		_ "github.com/microsoft/go-mssqldb" // sqlserver
+		_ "modernc.org/sqlite"              // sqlite
+	)
+*/
+package sqldb
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Dialect особенности SQL конкретной СУБД
+type Dialect interface {
+	Name() string                      // имя диалекта: sqlite, postgres, mssql
+	Driver() string                    // имя драйвера database/sql
+	Quote(name string) string          // имя таблицы или колонки в кавычках
+	Placeholder(n int) string          // n-й параметр запроса (с 1)
+	ColumnType(c Column) string        // тип колонки по типу поля
+	CreateTable(t Table) string        // создание таблицы, если её нет
+	Insert(t Table, rows int) string   // вставка rows строк
+	Upsert(t Table, rows int) string   // вставка rows строк с обновлением по ключу t.Keys
+	MaxParams() int                    // ограничение кол-ва параметров в запросе
+	MaxRows() int                      // ограничение кол-ва строк в одном INSERT ... VALUES (0 - нет)
+	DateValue(t time.Time) interface{} // значение даты для параметра запроса
+}
+
+// Column колонка таблицы
+type Column struct {
+	Name string // имя колонки (FieldExcel.Name)
+	Type string // тип поля (xlsx.FieldTypes()), пусто и пользовательские типы - строка
+	Key  bool   // колонка входит в ключ
+}
+
+// Table описание таблицы
+type Table struct {
+	Name    string
+	Columns []Column
+	Keys    []string // ключевые колонки (для upsert и первичного ключа)
+}
+
+// NewDialect диалект по имени: sqlite, postgres (postgresql, pg), mssql (sqlserver)
+func NewDialect(name string) (Dialect, error) {
+	switch strings.ToLower(name) {
+	case "sqlite", "sqlite3":
+		return sqliteDialect{}, nil
+	case "postgres", "postgresql", "pg":
+		return postgresDialect{}, nil
+	case "mssql", "sqlserver":
+		return mssqlDialect{}, nil
+	}
+	return nil, fmt.Errorf("неизвестный диалект СУБД %q (допустимы: sqlite, postgres, mssql)", name)
+}
+
+// quoteName имя в кавычках, схема отделяется точкой: dbo.payments -> "dbo"."payments"
+func quoteName(name, open, close string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = open + strings.ReplaceAll(p, close, close+close) + close
+	}
+	return strings.Join(parts, ".")
+}
+
+// columnList список колонок через запятую
+func columnList(d Dialect, columns []Column) string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = d.Quote(c.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// valuesList строки параметров: (?, ?), (?, ?)
+func valuesList(d Dialect, columns, rows int) string {
+	var b strings.Builder
+	n := 1
+	for r := 0; r < rows; r++ {
+		if r > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString("(")
+		for c := 0; c < columns; c++ {
+			if c > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(d.Placeholder(n))
+			n++
+		}
+		b.WriteString(")")
+	}
+	return b.String()
+}
+
+// columnDefs описания колонок и первичного ключа для CREATE TABLE
+func columnDefs(d Dialect, t Table) string {
+	defs := make([]string, 0, len(t.Columns)+1)
+	for _, c := range t.Columns {
+		defs = append(defs, d.Quote(c.Name)+" "+d.ColumnType(c))
+	}
+	if len(t.Keys) > 0 {
+		keys := make([]string, len(t.Keys))
+		for i, k := range t.Keys {
+			keys[i] = d.Quote(k)
+		}
+		defs = append(defs, "PRIMARY KEY ("+strings.Join(keys, ", ")+")")
+	}
+	return "\n\t" + strings.Join(defs, ",\n\t") + "\n"
+}
+
+// onConflictUpsert INSERT ... ON CONFLICT DO UPDATE (SQLite, PostgreSQL)
+func onConflictUpsert(d Dialect, t Table, rows int) string {
+	keys := make([]string, len(t.Keys))
+	for i, k := range t.Keys {
+		keys[i] = d.Quote(k)
+	}
+	var set []string
+	for _, c := range t.Columns {
+		if !c.Key {
+			set = append(set, fmt.Sprintf("%s = excluded.%s", d.Quote(c.Name), d.Quote(c.Name)))
+		}
+	}
+	action := "DO NOTHING"
+	if len(set) > 0 {
+		action = "DO UPDATE SET " + strings.Join(set, ", ")
+	}
+	return fmt.Sprintf("%s ON CONFLICT (%s) %s", d.Insert(t, rows), strings.Join(keys, ", "), action)
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string   { return "sqlite" }
+func (sqliteDialect) Driver() string { return "sqlite" }
+func (sqliteDialect) MaxParams() int { return 999 }
+func (sqliteDialect) MaxRows() int   { return 0 }
+
+func (sqliteDialect) Quote(name string) string { return quoteName(name, `"`, `"`) }
+func (sqliteDialect) Placeholder(int) string   { return "?" }
+
+// DateValue в SQLite нет типа даты, храним текстом yyyy-mm-dd (дату со временем - yyyy-mm-dd hh:mm:ss)
+func (sqliteDialect) DateValue(t time.Time) interface{} {
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0 {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+func (sqliteDialect) ColumnType(c Column) string {
+	switch c.Type {
+	case "int64":
+		return "INTEGER"
+	case "float64", "percent":
+		return "REAL"
+	case "decimal", "currency":
+		return "NUMERIC"
+	case "bool":
+		return "INTEGER"
+	case "date":
+		return "DATE"
+	case "datetime":
+		return "DATETIME"
+	}
+	return "TEXT"
+}
+
+func (d sqliteDialect) CreateTable(t Table) string {
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", d.Quote(t.Name), columnDefs(d, t))
+}
+
+func (d sqliteDialect) Insert(t Table, rows int) string {
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", d.Quote(t.Name), columnList(d, t.Columns), valuesList(d, len(t.Columns), rows))
+}
+
+func (d sqliteDialect) Upsert(t Table, rows int) string {
+	return onConflictUpsert(d, t, rows)
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string   { return "postgres" }
+func (postgresDialect) Driver() string { return "postgres" }
+func (postgresDialect) MaxParams() int { return 65535 }
+func (postgresDialect) MaxRows() int   { return 0 }
+
+func (postgresDialect) Quote(name string) string { return quoteName(name, `"`, `"`) }
+func (postgresDialect) Placeholder(n int) string { return fmt.Sprintf("$%d", n) }
+
+func (postgresDialect) DateValue(t time.Time) interface{} { return t }
+
+func (postgresDialect) ColumnType(c Column) string {
+	switch c.Type {
+	case "int64":
+		return "BIGINT"
+	case "float64", "percent":
+		return "DOUBLE PRECISION"
+	case "decimal", "currency":
+		return "NUMERIC"
+	case "bool":
+		return "BOOLEAN"
+	case "date":
+		return "DATE"
+	case "datetime":
+		return "TIMESTAMP"
+	case "time":
+		return "TIME"
+	case "duration":
+		return "INTERVAL"
+	}
+	return "TEXT"
+}
+
+func (d postgresDialect) CreateTable(t Table) string {
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", d.Quote(t.Name), columnDefs(d, t))
+}
+
+func (d postgresDialect) Insert(t Table, rows int) string {
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", d.Quote(t.Name), columnList(d, t.Columns), valuesList(d, len(t.Columns), rows))
+}
+
+func (d postgresDialect) Upsert(t Table, rows int) string {
+	return onConflictUpsert(d, t, rows)
+}
+
+type mssqlDialect struct{}
+
+func (mssqlDialect) Name() string   { return "mssql" }
+func (mssqlDialect) Driver() string { return "sqlserver" }
+func (mssqlDialect) MaxParams() int { return 2100 }
+func (mssqlDialect) MaxRows() int   { return 1000 } // больше 1000 строк в VALUES MS SQL не принимает
+
+func (mssqlDialect) Quote(name string) string { return quoteName(name, "[", "]") }
+func (mssqlDialect) Placeholder(n int) string { return fmt.Sprintf("@p%d", n) }
+
+func (mssqlDialect) DateValue(t time.Time) interface{} { return t }
+
+func (mssqlDialect) ColumnType(c Column) string {
+	switch c.Type {
+	case "int64":
+		return "BIGINT"
+	case "float64", "percent":
+		return "FLOAT"
+	case "decimal":
+		return "DECIMAL(38,10)"
+	case "currency":
+		return "DECIMAL(19,4)"
+	case "bool":
+		return "BIT"
+	case "date":
+		return "DATE"
+	case "datetime":
+		return "DATETIME2"
+	case "time":
+		return "TIME"
+	case "duration":
+		return "NVARCHAR(32)"
+	}
+	if c.Key { // ключ не может быть NVARCHAR(MAX)
+		return "NVARCHAR(450)"
+	}
+	return "NVARCHAR(MAX)"
+}
+
+func (d mssqlDialect) CreateTable(t Table) string {
+	return fmt.Sprintf("IF OBJECT_ID(N'%s', N'U') IS NULL CREATE TABLE %s (%s)",
+		strings.ReplaceAll(t.Name, "'", "''"), d.Quote(t.Name), columnDefs(d, t))
+}
+
+func (d mssqlDialect) Insert(t Table, rows int) string {
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", d.Quote(t.Name), columnList(d, t.Columns), valuesList(d, len(t.Columns), rows))
+}
+
+// Upsert для MS SQL - MERGE по ключевым колонкам
+func (d mssqlDialect) Upsert(t Table, rows int) string {
+	var on, set []string
+	for _, k := range t.Keys {
+		on = append(on, fmt.Sprintf("t.%s = s.%s", d.Quote(k), d.Quote(k)))
+	}
+	src := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		src[i] = "s." + d.Quote(c.Name)
+		if !c.Key {
+			set = append(set, fmt.Sprintf("t.%s = s.%s", d.Quote(c.Name), d.Quote(c.Name)))
+		}
+	}
+	query := fmt.Sprintf("MERGE INTO %s AS t USING (VALUES %s) AS s (%s) ON %s",
+		d.Quote(t.Name), valuesList(d, len(t.Columns), rows), columnList(d, t.Columns), strings.Join(on, " AND "))
+	if len(set) > 0 {
+		query += " WHEN MATCHED THEN UPDATE SET " + strings.Join(set, ", ")
+	}
+	return query + fmt.Sprintf(" WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s);",
+		columnList(d, t.Columns), strings.Join(src, ", "))
+}
@@ -0,0 +1,253 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"read_write_xlsx/pkg/xlsx"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Режимы транзакций выгрузки
+const (
+	TxAll   = "all"   // одна транзакция на всю выгрузку (по умолчанию)
+	TxBatch = "batch" // транзакция на каждый пакет строк
+	TxNone  = "none"  // без транзакций
+)
+
+// ExportSettings настройки выгрузки записей в таблицу БД
+type ExportSettings struct {
+	Driver      string   `json:"driver"`                 // диалект СУБД: sqlite, postgres, mssql
+	DSN         string   `json:"dsn"`                    // строка подключения драйвера
+	Table       string   `json:"table"`                  // таблица (можно со схемой: dbo.payments)
+	CreateTable bool     `json:"create_table,omitempty"` // создать таблицу по типам полей, если её нет
+	BatchSize   int      `json:"batch_size,omitempty"`   // кол-во строк в одном INSERT (по умолчанию 500)
+	Transaction string   `json:"transaction,omitempty"`  // транзакции: all, batch, none
+	UpsertKeys  []string `json:"upsert_keys,omitempty"`  // ключевые колонки: существующие строки обновляются
+	DryRun      bool     `json:"dry_run,omitempty"`      // только показать DDL и запрос, без подключения к БД
+}
+
+// Exporter выгрузка записей ExcelToData в таблицу БД
+type Exporter struct {
+	opts    ExportSettings
+	dialect Dialect
+	table   Table
+	fields  []xlsx.FieldExcel // поля по порядку колонок таблицы
+}
+
+// NewExporter готовим выгрузку: колонки таблицы - поля fields с заданным Name в порядке ключей
+func NewExporter(opts ExportSettings, fields map[int]xlsx.FieldExcel) (*Exporter, error) {
+	d, err := NewDialect(opts.Driver)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Table == "" {
+		return nil, fmt.Errorf("не задана таблица для выгрузки")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+	switch opts.Transaction {
+	case "":
+		opts.Transaction = TxAll
+	case TxAll, TxBatch, TxNone:
+	default:
+		return nil, fmt.Errorf("неизвестный режим транзакций %q (допустимы: all, batch, none)", opts.Transaction)
+	}
+
+	e := &Exporter{opts: opts, dialect: d, table: Table{Name: opts.Table, Keys: opts.UpsertKeys}}
+	keys := make([]int, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	isKey := make(map[string]bool, len(opts.UpsertKeys))
+	for _, k := range opts.UpsertKeys {
+		isKey[k] = true
+	}
+	seen := make(map[string]bool, len(fields))
+	for _, k := range keys {
+		v := fields[k]
+		if v.Name == "" || seen[v.Name] {
+			continue
+		}
+		seen[v.Name] = true
+		e.table.Columns = append(e.table.Columns, Column{Name: v.Name, Type: v.Type, Key: isKey[v.Name]})
+		e.fields = append(e.fields, v)
+	}
+	if len(e.table.Columns) == 0 {
+		return nil, fmt.Errorf("нет полей для выгрузки в таблицу %v", opts.Table)
+	}
+	for _, k := range opts.UpsertKeys {
+		if !seen[k] {
+			return nil, fmt.Errorf("ключевая колонка %v не найдена среди полей", k)
+		}
+	}
+	// пакет не должен превышать ограничения СУБД на кол-во параметров и строк в запросе
+	max := d.MaxParams() / len(e.table.Columns)
+	if rows := d.MaxRows(); rows > 0 && rows < max {
+		max = rows
+	}
+	if e.opts.BatchSize > max {
+		e.opts.BatchSize = max
+	}
+	return e, nil
+}
+
+// Open подключение к БД по настройкам выгрузки
+func (e *Exporter) Open() (*sql.DB, error) {
+	db, err := sql.Open(e.dialect.Driver(), e.opts.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("sql.Open %v", err)
+	}
+	return db, nil
+}
+
+// DDL запрос создания таблицы
+func (e *Exporter) DDL() string {
+	return e.dialect.CreateTable(e.table)
+}
+
+// Query запрос вставки rows строк (с обновлением при заданных ключах)
+func (e *Exporter) Query(rows int) string {
+	if len(e.table.Keys) > 0 {
+		return e.dialect.Upsert(e.table, rows)
+	}
+	return e.dialect.Insert(e.table, rows)
+}
+
+// execer выполнение запросов: *sql.DB или *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Export выгружаем записи data в таблицу, возвращает кол-во выгруженных записей
+func (e *Exporter) Export(ctx context.Context, db *sql.DB, data []map[string]interface{}) (int, error) {
+	if e.opts.CreateTable {
+		if _, err := db.ExecContext(ctx, e.DDL()); err != nil {
+			return 0, fmt.Errorf("создание таблицы %v: %w", e.table.Name, err)
+		}
+	}
+
+	var (
+		ex  execer = db
+		tx  *sql.Tx
+		err error
+	)
+	if e.opts.Transaction == TxAll {
+		if tx, err = db.BeginTx(ctx, nil); err != nil {
+			return 0, err
+		}
+		defer tx.Rollback()
+		ex = tx
+	}
+
+	count := 0
+	for start := 0; start < len(data); start += e.opts.BatchSize {
+		end := start + e.opts.BatchSize
+		if end > len(data) {
+			end = len(data)
+		}
+		if err = e.exportBatch(ctx, db, ex, data[start:end], start); err != nil {
+			return count, err
+		}
+		count = end
+	}
+
+	if tx != nil {
+		if err = tx.Commit(); err != nil {
+			return 0, fmt.Errorf("Commit %v", err)
+		}
+	}
+	return count, nil
+}
+
+// exportBatch вставка пакета строк, offset - номер первой записи пакета в данных
+func (e *Exporter) exportBatch(ctx context.Context, db *sql.DB, ex execer, batch []map[string]interface{}, offset int) error {
+	if len(e.table.Keys) > 0 {
+		batch = lastByKey(batch, e.table.Keys)
+	}
+	args := make([]interface{}, 0, len(batch)*len(e.fields))
+	for i, rec := range batch {
+		for _, v := range e.fields {
+			val, err := dbValue(v, rec[v.Name])
+			if err != nil {
+				return fmt.Errorf("запись %d, поле %v: %w", offset+i+1, v.Name, err)
+			}
+			if t, ok := val.(time.Time); ok {
+				val = e.dialect.DateValue(t)
+			}
+			args = append(args, val)
+		}
+	}
+
+	if e.opts.Transaction == TxBatch {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		ex = tx
+	}
+	if _, err := ex.ExecContext(ctx, e.Query(len(batch)), args...); err != nil {
+		return fmt.Errorf("записи %d-%d: %w", offset+1, offset+len(batch), err)
+	}
+	if tx, ok := ex.(*sql.Tx); ok && e.opts.Transaction == TxBatch {
+		return tx.Commit()
+	}
+	return nil
+}
+
+// lastByKey оставляем в пакете последнюю запись для каждого ключа:
+// PostgreSQL и MS SQL не обновляют одну строку дважды в одном запросе
+func lastByKey(batch []map[string]interface{}, keys []string) []map[string]interface{} {
+	pos := make(map[string]int, len(batch))
+	result := make([]map[string]interface{}, 0, len(batch))
+	for _, rec := range batch {
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = fmt.Sprint(rec[k])
+		}
+		id := strings.Join(parts, "\x00")
+		if i, ok := pos[id]; ok {
+			result[i] = rec
+			continue
+		}
+		pos[id] = len(result)
+		result = append(result, rec)
+	}
+	return result
+}
+
+// dbValue значение записи для параметра запроса
+func dbValue(v xlsx.FieldExcel, val interface{}) (interface{}, error) {
+	if str, ok := val.(string); ok && strings.TrimSpace(str) == "" {
+		if !v.IsText() {
+			return nil, nil // пустая ячейка типизированного поля - NULL
+		}
+		return str, nil
+	}
+	if d, ok := val.(time.Duration); ok {
+		return xlsx.FormatDuration(d), nil
+	}
+	if v.Type != "date" && v.Type != "datetime" {
+		return val, nil
+	}
+	switch x := val.(type) {
+	case time.Time:
+		return x, nil
+	case string:
+		layout := v.ParseFormat
+		if layout == "" {
+			layout = "02.01.2006"
+		}
+		t, err := time.Parse(layout, strings.TrimSpace(x))
+		if err != nil {
+			return nil, err
+		}
+		return t, nil
+	}
+	return val, nil
+}
@@ -0,0 +1,129 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"read_write_xlsx/pkg/xlsx"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExportBatchSize(t *testing.T) {
+	fields := func(n int) map[int]xlsx.FieldExcel {
+		res := make(map[int]xlsx.FieldExcel, n)
+		for i := 1; i <= n; i++ {
+			res[i] = xlsx.FieldExcel{Name: string(rune('a' - 1 + i))}
+		}
+		return res
+	}
+	tests := []struct {
+		driver  string
+		batch   int
+		columns int
+		want    int
+	}{
+		{"mssql", 5000, 1, 1000}, // не больше 1000 строк в VALUES
+		{"mssql", 5000, 3, 700},  // 2100 параметров / 3 колонки
+		{"mssql", 0, 2, 500},     // по умолчанию
+		{"sqlite", 5000, 2, 499}, // 999 параметров / 2 колонки
+		{"postgres", 5000, 1, 5000},
+	}
+	for _, tt := range tests {
+		e, err := NewExporter(ExportSettings{Driver: tt.driver, Table: "t", BatchSize: tt.batch}, fields(tt.columns))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.opts.BatchSize != tt.want {
+			t.Errorf("%v, batch_size %d, колонок %d: пакет %d, ожидалось %d", tt.driver, tt.batch, tt.columns, e.opts.BatchSize, tt.want)
+		}
+	}
+}
+
+// paymentFields поля выгрузки платежей
+var paymentFields = map[int]xlsx.FieldExcel{
+	1: {Name: "account", Type: "int64"},
+	2: {Name: "fio"},
+	3: {Name: "paid", Type: "date", ParseFormat: "02.01.2006"},
+	4: {Name: "sum", Type: "int64"},
+}
+
+// openExport выгрузка в таблицу payments новой базы SQLite
+func openExport(t *testing.T, opts ExportSettings) (*Exporter, *sql.DB) {
+	t.Helper()
+	opts.Driver, opts.DSN, opts.Table, opts.CreateTable = "sqlite", filepath.Join(t.TempDir(), "pay.db"), "payments", true
+	ex, err := NewExporter(opts, paymentFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := ex.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return ex, db
+}
+
+func TestExportUpsert(t *testing.T) {
+	ex, db := openExport(t, ExportSettings{BatchSize: 2, Transaction: TxBatch, UpsertKeys: []string{"account"}})
+	if ddl := ex.DDL(); !strings.Contains(ddl, `PRIMARY KEY ("account")`) {
+		t.Errorf("DDL без первичного ключа: %v", ddl)
+	}
+	ctx := context.Background()
+
+	data := []map[string]interface{}{
+		{"account": int64(1001), "fio": "Иванов", "paid": "01.03.2023", "sum": int64(10)},
+		{"account": int64(1001), "fio": "Иванова", "paid": "01.03.2023", "sum": int64(10)}, // в одном пакете остаётся последняя
+		{"account": int64(1002), "fio": "Петров", "paid": "02.03.2023", "sum": ""},         // пустая сумма - NULL
+	}
+	if n, err := ex.Export(ctx, db, data); err != nil || n != len(data) {
+		t.Fatalf("Export = %d, %v; ожидалось %d записей", n, err, len(data))
+	}
+	var nulls int
+	if err := db.QueryRow(`SELECT count(*) FROM payments WHERE sum IS NULL`).Scan(&nulls); err != nil || nulls != 1 {
+		t.Errorf("строк с пустой суммой %d (%v), ожидалась 1", nulls, err)
+	}
+
+	update := []map[string]interface{}{{"account": int64(1002), "fio": "Петров", "paid": "02.03.2023", "sum": int64(5)}}
+	if _, err := ex.Export(ctx, db, update); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := db.Query(`SELECT account, fio, paid, sum FROM payments ORDER BY account`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var (
+			account, sum int64
+			fio, paid    string
+		)
+		if err := rows.Scan(&account, &fio, &paid, &sum); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, fmt.Sprintf("%d %s %s %d", account, fio, paid[:10], sum))
+	}
+	if want := []string{"1001 Иванова 2023-03-01 10", "1002 Петров 2023-03-02 5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("таблица payments: %q, ожидалось %q", got, want)
+	}
+}
+
+func TestExportRollback(t *testing.T) {
+	ex, db := openExport(t, ExportSettings{BatchSize: 1})
+	data := []map[string]interface{}{
+		{"account": int64(1001), "fio": "Иванов", "paid": "01.03.2023", "sum": int64(10)},
+		{"account": int64(1002), "fio": "Петров", "paid": "31.02.2023", "sum": int64(20)},
+	}
+	_, err := ex.Export(context.Background(), db, data)
+	if err == nil || !strings.Contains(err.Error(), "запись 2, поле paid") {
+		t.Fatalf("Export: ошибка %v, ожидалась ошибка в записи 2", err)
+	}
+	var count int
+	if err = db.QueryRow(`SELECT count(*) FROM payments`).Scan(&count); err != nil || count != 0 {
+		t.Errorf("после ошибки в таблице %d строк (%v), транзакция не отменена", count, err)
+	}
+}
@@ -0,0 +1,152 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"read_write_xlsx/pkg/xlsx"
+	"strings"
+)
+
+// QuerySettings настройки вывода результата запроса в файл
+type QuerySettings struct {
+	Driver string `json:"driver"`          // диалект СУБД: sqlite, postgres, mssql
+	DSN    string `json:"dsn"`             // строка подключения драйвера
+	Query  string `json:"query"`           // запрос, колонки результата - имена полей (FieldExcel.Name)
+	Sheet  string `json:"sheet,omitempty"` // лист вывода (по умолчанию "Вывод")
+}
+
+// QueryRows построчное чтение результата запроса
+type QueryRows struct {
+	rows    *sql.Rows
+	columns []*sql.ColumnType
+	values  []interface{}
+	ptrs    []interface{}
+}
+
+// Open подключение к БД по настройкам
+func (q QuerySettings) Open() (*sql.DB, error) {
+	d, err := NewDialect(q.Driver)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(d.Driver(), q.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("sql.Open %v", err)
+	}
+	return db, nil
+}
+
+// Query выполняем запрос
+func Query(ctx context.Context, db *sql.DB, query string, args ...interface{}) (*QueryRows, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := rows.ColumnTypes()
+	if err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("ColumnTypes %v", err)
+	}
+	q := &QueryRows{rows: rows, columns: columns, values: make([]interface{}, len(columns)), ptrs: make([]interface{}, len(columns))}
+	for i := range q.values {
+		q.ptrs[i] = &q.values[i]
+	}
+	return q, nil
+}
+
+// Fields поля вывода по колонкам результата. Заданные поля fields сохраняются, у полей с именем колонки
+// незаданные type/format выводятся из типа колонки. Если fields пусто - выводятся все колонки по порядку.
+func (q *QueryRows) Fields(fields map[int]xlsx.FieldExcel) map[int]xlsx.FieldExcel {
+	result := make(map[int]xlsx.FieldExcel, len(q.columns))
+	if len(fields) == 0 {
+		for i, c := range q.columns {
+			typ, format := columnFieldType(c)
+			result[i+1] = xlsx.FieldExcel{Name: c.Name(), Header: c.Name(), Type: typ, Format: format}
+		}
+		return result
+	}
+	byName := make(map[string]*sql.ColumnType, len(q.columns))
+	for _, c := range q.columns {
+		byName[c.Name()] = c
+	}
+	for key, v := range fields {
+		if c, ok := byName[v.Name]; ok && v.Type == "" {
+			typ, format := columnFieldType(c)
+			v.Type = typ
+			if v.Format == "" {
+				v.Format = format
+			}
+		}
+		result[key] = v
+	}
+	return result
+}
+
+// Next очередная запись результата (колонка -> значение), io.EOF - записей больше нет
+func (q *QueryRows) Next() (map[string]interface{}, error) {
+	if !q.rows.Next() {
+		if err := q.rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	if err := q.rows.Scan(q.ptrs...); err != nil {
+		return nil, fmt.Errorf("Scan %v", err)
+	}
+	record := make(map[string]interface{}, len(q.columns))
+	for i, c := range q.columns {
+		switch x := q.values[i].(type) {
+		case []byte: // текст и DECIMAL/NUMERIC драйверы возвращают байтами
+			record[c.Name()] = string(x)
+		case int32:
+			record[c.Name()] = int64(x)
+		case float32:
+			record[c.Name()] = float64(x)
+		default:
+			record[c.Name()] = x
+		}
+	}
+	return record, nil
+}
+
+// Close закрывает результат запроса
+func (q *QueryRows) Close() error {
+	return q.rows.Close()
+}
+
+// QueryToExcel выполняем запрос и записываем результат в файл filename на лист sheet (Excel или CSV).
+// Строки результата не накапливаются в памяти, файл сохраняется с настройками opts.
+func QueryToExcel(ctx context.Context, db *sql.DB, query, filename, sheet string, fields map[int]xlsx.FieldExcel, opts xlsx.SaveOptions, log xlsx.Logger) error {
+	q, err := Query(ctx, db, query)
+	if err != nil {
+		return err
+	}
+	defer q.Close()
+
+	fe := xlsx.NewFieldsExcel(sheet, q.Fields(fields), log)
+	fe.SetSaveOptions(opts)
+	return fe.StreamToExcel(filename, 1, q.Next)
+}
+
+// columnFieldType тип и формат поля по типу колонки результата
+func columnFieldType(c *sql.ColumnType) (string, string) {
+	name := strings.ToUpper(c.DatabaseTypeName())
+	if i := strings.IndexByte(name, '('); i > 0 {
+		name = name[:i]
+	}
+	switch name {
+	case "INT", "INTEGER", "BIGINT", "SMALLINT", "TINYINT", "MEDIUMINT", "INT2", "INT4", "INT8", "SERIAL", "BIGSERIAL":
+		return "int64", ""
+	case "REAL", "FLOAT", "FLOAT4", "FLOAT8", "DOUBLE", "DOUBLE PRECISION":
+		return "float64", "#,##0.00"
+	case "NUMERIC", "DECIMAL": // точные числа: целые значения драйверы возвращают int64
+		return "decimal", "#,##0.00"
+	case "MONEY", "SMALLMONEY": // PostgreSQL возвращает суммы текстом со знаком валюты
+		return "currency", "#,##0.00"
+	case "DATE", "DATETIME", "DATETIME2", "SMALLDATETIME", "TIMESTAMP", "TIMESTAMPTZ", "DATETIMEOFFSET":
+		return "date", "dd.mm.yyyy"
+	}
+	return "", ""
+}
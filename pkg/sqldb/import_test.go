@@ -0,0 +1,79 @@
+package sqldb
+
+import (
+	"context"
+	"path/filepath"
+	"read_write_xlsx/pkg/xlsx"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// nopLogger логгер для тестов
+type nopLogger struct{}
+
+func (nopLogger) Debug(args ...interface{})                 {}
+func (nopLogger) Debugf(format string, args ...interface{}) {}
+func (nopLogger) Error(args ...interface{})                 {}
+func (nopLogger) Errorf(format string, args ...interface{}) {}
+
+func TestSQLiteRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	dsn := filepath.Join(dir, "pay.db")
+	fields := map[int]xlsx.FieldExcel{
+		1: {Name: "account", Type: "int64"},
+		2: {Name: "amount", Type: "decimal"},
+		3: {Name: "paid", Type: "date"},
+		4: {Name: "fio"},
+	}
+	dec := func(s string) xlsx.Decimal {
+		d, err := xlsx.ParseDecimal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	paid := time.Date(2023, time.March, 15, 0, 0, 0, 0, time.UTC)
+	data := []map[string]interface{}{
+		{"account": int64(1001), "amount": dec("100"), "paid": paid, "fio": "Иванов"},
+		{"account": int64(1002), "amount": dec("12.34"), "paid": paid.AddDate(0, 0, 1), "fio": "Петров"},
+	}
+
+	ex, err := NewExporter(ExportSettings{Driver: "sqlite", DSN: dsn, Table: "payments", CreateTable: true}, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := ex.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	if n, err := ex.Export(ctx, db, data); err != nil || n != len(data) {
+		t.Fatalf("Export = %d, %v; ожидалось %d записей", n, err, len(data))
+	}
+
+	// тип результата запроса выводится из типа колонки: NUMERIC - decimal (целые значения драйвер возвращает int64)
+	filename := filepath.Join(dir, "out.xlsx")
+	query := "SELECT account, amount, paid, fio FROM payments ORDER BY account"
+	if err := QueryToExcel(ctx, db, query, filename, "Вывод", nil, xlsx.SaveOptions{}, nopLogger{}); err != nil {
+		t.Fatal(err)
+	}
+
+	fe := xlsx.NewFieldsExcel("Вывод", fields, nopLogger{})
+	got, err := fe.ExcelToData(filename, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(data) {
+		t.Fatalf("прочитано записей %d, ожидалось %d", len(got), len(data))
+	}
+	for i, want := range data {
+		for _, name := range []string{"account", "amount", "paid", "fio"} {
+			if g, w := got[i][name], want[name]; g != w {
+				t.Errorf("запись %d, поле %v = %v (%T), ожидалось %v (%T)", i+1, name, g, g, w, w)
+			}
+		}
+	}
+}
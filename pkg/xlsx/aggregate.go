@@ -0,0 +1,422 @@
+package xlsx
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Функции агрегирования
+const (
+	AggSum      = "sum"
+	AggCount    = "count"
+	AggAvg      = "avg"
+	AggMin      = "min"
+	AggMax      = "max"
+	AggDistinct = "distinct" // кол-во различных значений
+)
+
+// SummaryField поле группировки или показатель сводки
+type SummaryField struct {
+	Field  string  `json:"field"`            // имя поля записи (FieldExcel.Name)
+	Func   string  `json:"func,omitempty"`   // для показателей: sum, count, avg, min, max, distinct
+	Name   string  `json:"name,omitempty"`   // заголовок колонки (по умолчанию - имя поля)
+	Format string  `json:"format,omitempty"` // числовой формат колонки
+	Width  float64 `json:"width,omitempty"`  // ширина колонки
+}
+
+// SummarySettings описание сводки, вычисляемой в Go (без сводных таблиц Excel)
+type SummarySettings struct {
+	Sheet      string         `json:"sheet"`                 // лист сводки (пересоздаётся)
+	GroupBy    []SummaryField `json:"group_by"`              // поля группировки по порядку уровней
+	Measures   []SummaryField `json:"measures"`              // показатели
+	Subtotals  bool           `json:"subtotals,omitempty"`   // промежуточные итоги по уровням группировки
+	GrandTotal *bool          `json:"grand_total,omitempty"` // общий итог (по умолчанию true)
+}
+
+// SummaryRow строка результата группировки
+type SummaryRow struct {
+	Keys   []interface{} // значения полей группировки
+	Level  int           // кол-во заполненных ключей: len(GroupBy) - группа, меньше - промежуточный итог, 0 - общий итог
+	Values []interface{} // значения показателей (float64, Decimal для сумм полей decimal/currency, int64 для count/distinct, nil - нет значений)
+}
+
+// IsTotal строка промежуточного или общего итога
+func (r SummaryRow) IsTotal(groups int) bool {
+	return r.Level < groups
+}
+
+// aggregator накопление значений показателя
+type aggregator struct {
+	fn       string
+	count    int64
+	sum      float64
+	dec      Decimal // точная сумма, пока все значения Decimal
+	exact    bool
+	min, max interface{}
+	distinct map[string]bool
+}
+
+func (a *aggregator) add(val interface{}) error {
+	if isEmptyValue(val) {
+		return nil
+	}
+	switch a.fn {
+	case AggCount:
+		a.count++
+	case AggDistinct:
+		a.distinct[fmt.Sprint(val)] = true
+	case AggSum, AggAvg:
+		n, err := numberValue(val)
+		if err != nil {
+			return err
+		}
+		a.sum += n
+		d, isDec := val.(Decimal)
+		if a.exact = isDec && (a.count == 0 || a.exact); a.exact {
+			if a.dec, err = a.dec.Add(d); err != nil {
+				a.exact = false // переполнение - дальше считаем в float64
+			}
+		}
+		a.count++
+	case AggMin, AggMax:
+		if n, err := numberValue(val); err == nil {
+			val = n
+		} else if _, ok := val.(time.Time); !ok {
+			return err
+		}
+		if a.count == 0 || (a.fn == AggMin && compareValues(val, a.min) < 0) {
+			a.min = val
+		}
+		if a.count == 0 || (a.fn == AggMax && compareValues(val, a.max) > 0) {
+			a.max = val
+		}
+		a.count++
+	}
+	return nil
+}
+
+func (a *aggregator) result() interface{} {
+	switch a.fn {
+	case AggCount:
+		return a.count
+	case AggDistinct:
+		return int64(len(a.distinct))
+	}
+	if a.count == 0 {
+		return nil
+	}
+	switch a.fn {
+	case AggSum:
+		if a.exact {
+			return a.dec
+		}
+		return a.sum
+	case AggAvg:
+		if a.exact {
+			return a.dec.Float64() / float64(a.count)
+		}
+		return a.sum / float64(a.count)
+	case AggMin:
+		return a.min
+	}
+	return a.max
+}
+
+// Aggregate группируем записи по полям groupBy и вычисляем показатели measures.
+// Строки групп упорядочены по значениям ключей; при subtotals после каждой группы верхнего уровня
+// выводятся промежуточные итоги, при grandTotal последней строкой - общий итог.
+func Aggregate(data []map[string]interface{}, groupBy, measures []SummaryField, subtotals, grandTotal bool) ([]SummaryRow, error) {
+	for _, m := range measures {
+		switch m.Func {
+		case AggSum, AggCount, AggAvg, AggMin, AggMax, AggDistinct:
+		default:
+			return nil, fmt.Errorf("показатель %v: неизвестная функция %q (допустимы: sum, count, avg, min, max, distinct)", m.Field, m.Func)
+		}
+	}
+
+	type group struct {
+		keys []interface{}
+		aggs []*aggregator
+	}
+	newGroup := func(keys []interface{}) *group {
+		g := &group{keys: keys, aggs: make([]*aggregator, len(measures))}
+		for i, m := range measures {
+			g.aggs[i] = &aggregator{fn: m.Func}
+			if m.Func == AggDistinct {
+				g.aggs[i].distinct = make(map[string]bool)
+			}
+		}
+		return g
+	}
+
+	levels := len(groupBy)
+	groups := make([]map[string]*group, levels+1) // уровень -> ключ группы -> группа
+	for i := range groups {
+		groups[i] = make(map[string]*group)
+	}
+	for r, rec := range data {
+		keys := make([]interface{}, levels)
+		for i, g := range groupBy {
+			keys[i] = rec[g.Field]
+		}
+		for level := 0; level <= levels; level++ {
+			id := groupID(keys[:level])
+			g, ok := groups[level][id]
+			if !ok {
+				g = newGroup(keys[:level])
+				groups[level][id] = g
+			}
+			for i, m := range measures {
+				if err := g.aggs[i].add(rec[m.Field]); err != nil {
+					return nil, fmt.Errorf("запись %d, поле %v: %w", r+1, m.Field, err)
+				}
+			}
+		}
+	}
+
+	leaves := make([]*group, 0, len(groups[levels]))
+	for _, g := range groups[levels] {
+		leaves = append(leaves, g)
+	}
+	sort.Slice(leaves, func(i, j int) bool {
+		for k := range leaves[i].keys {
+			if c := compareValues(leaves[i].keys[k], leaves[j].keys[k]); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+
+	toRow := func(g *group) SummaryRow {
+		row := SummaryRow{Keys: g.keys, Level: len(g.keys), Values: make([]interface{}, len(measures))}
+		for i, a := range g.aggs {
+			row.Values[i] = a.result()
+		}
+		return row
+	}
+	result := make([]SummaryRow, 0, len(leaves))
+	for i, g := range leaves {
+		if levels == 0 {
+			break // без группировки только общий итог
+		}
+		result = append(result, toRow(g))
+		if !subtotals {
+			continue
+		}
+		// закрываем уровни, на которых следующая группа отличается
+		for level := levels - 1; level >= 1; level-- {
+			if i+1 < len(leaves) && groupID(leaves[i+1].keys[:level]) == groupID(g.keys[:level]) {
+				break
+			}
+			result = append(result, toRow(groups[level][groupID(g.keys[:level])]))
+		}
+	}
+	if grandTotal || levels == 0 {
+		result = append(result, toRow(groups[0][""]))
+	}
+	return result, nil
+}
+
+// WriteSummary вычисляем сводку по записям data и выводим её на лист s.Sheet.
+// Строки итогов выделяются жирным шрифтом.
+func (wb *Workbook) WriteSummary(s SummarySettings, data []map[string]interface{}) error {
+	if s.Sheet == "" {
+		return fmt.Errorf("сводка: не задан лист")
+	}
+	if len(s.Measures) == 0 {
+		return fmt.Errorf("сводка %v: не заданы показатели", s.Sheet)
+	}
+	grandTotal := s.GrandTotal == nil || *s.GrandTotal
+	rows, err := Aggregate(data, s.GroupBy, s.Measures, s.Subtotals, grandTotal)
+	if err != nil {
+		return fmt.Errorf("сводка %v: %w", s.Sheet, err)
+	}
+
+	f := wb.f
+	if err = wb.newSheet(s.Sheet); err != nil {
+		return err
+	}
+	sw, err := f.NewStreamWriter(s.Sheet)
+	if err != nil {
+		return fmt.Errorf("NewStreamWriter %v", err)
+	}
+
+	columns := append(append([]SummaryField{}, s.GroupBy...), s.Measures...)
+	bold, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	styles := make([]int, len(columns))     // стиль колонки
+	boldStyles := make([]int, len(columns)) // стиль колонки в строке итогов
+	formats := make([]string, len(columns)) // формат колонки
+	// даты в колонках без формата выводим как дд.мм.гггг
+	dateStyle, err := f.NewStyle(&excelize.Style{NumFmt: 14})
+	if err != nil {
+		return err
+	}
+	boldDateStyle, err := f.NewStyle(&excelize.Style{NumFmt: 14, Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	header := make([]interface{}, len(columns))
+	for i, c := range columns {
+		name := c.Name
+		if name == "" {
+			name = c.Field
+		}
+		header[i] = excelize.Cell{StyleID: bold, Value: name}
+		width := c.Width
+		if width == 0 {
+			width = float64(len([]rune(name)) + 5)
+		}
+		if err = sw.SetColWidth(i+1, i+1, width); err != nil {
+			return fmt.Errorf("SetColWidth %v", err)
+		}
+		format := c.Format
+		if format == "" && i >= len(s.GroupBy) {
+			switch c.Func {
+			case AggCount, AggDistinct:
+				format = "0"
+			case AggSum, AggAvg:
+				format = "#,##0.00"
+			}
+		}
+		boldStyles[i] = bold
+		formats[i] = format
+		if format != "" {
+			if styles[i], err = f.NewStyle(&excelize.Style{CustomNumFmt: &format}); err != nil {
+				return err
+			}
+			if boldStyles[i], err = f.NewStyle(&excelize.Style{CustomNumFmt: &format, Font: &excelize.Font{Bold: true}}); err != nil {
+				return err
+			}
+		}
+	}
+	if err = sw.SetRow("A1", header); err != nil {
+		return fmt.Errorf("SetRow %v", err)
+	}
+
+	levels := len(s.GroupBy)
+	for r, row := range rows {
+		st, dst := styles, dateStyle
+		if row.IsTotal(levels) {
+			st, dst = boldStyles, boldDateStyle
+		}
+		cell := func(i int, val interface{}) excelize.Cell {
+			val = excelValue(FieldExcel{}, val)
+			if _, ok := val.(time.Time); ok && formats[i] == "" {
+				return excelize.Cell{StyleID: dst, Value: val}
+			}
+			return excelize.Cell{StyleID: st[i], Value: val}
+		}
+		line := make([]interface{}, len(columns))
+		for i := 0; i < levels; i++ {
+			var val interface{}
+			switch {
+			case i < row.Level:
+				val = row.Keys[i]
+			case i == row.Level && row.Level == 0:
+				val = "Общий итог"
+			case i == row.Level:
+				val = "Итого"
+			}
+			line[i] = cell(i, val)
+		}
+		for i, v := range row.Values {
+			line[levels+i] = cell(levels+i, v)
+		}
+		addr, _ := excelize.CoordinatesToCellName(1, r+2)
+		if err = sw.SetRow(addr, line); err != nil {
+			return fmt.Errorf("SetRow %v", err)
+		}
+	}
+	return sw.Flush()
+}
+
+// groupID ключ группы для map
+func groupID(keys []interface{}) string {
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%T:%v", k, k)
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// isEmptyValue пустое значение поля
+func isEmptyValue(val interface{}) bool {
+	switch x := val.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(x) == ""
+	}
+	return false
+}
+
+// numberValue число из значения поля
+func numberValue(val interface{}) (float64, error) {
+	switch x := val.(type) {
+	case float64:
+		return x, nil
+	case float32:
+		return float64(x), nil
+	case int64:
+		return float64(x), nil
+	case int:
+		return float64(x), nil
+	case Decimal:
+		return x.Float64(), nil
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		if err != nil {
+			return 0, fmt.Errorf("значение %q не число", x)
+		}
+		return n, nil
+	}
+	return 0, fmt.Errorf("значение %v (%T) не число", val, val)
+}
+
+// compareValues сравнение значений: числа - как числа, даты - как даты, остальное - как строки.
+// Пустые значения меньше любых других.
+func compareValues(a, b interface{}) int {
+	switch {
+	case isEmptyValue(a) && isEmptyValue(b):
+		return 0
+	case isEmptyValue(a):
+		return -1
+	case isEmptyValue(b):
+		return 1
+	}
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			switch {
+			case ta.Before(tb):
+				return -1
+			case ta.After(tb):
+				return 1
+			}
+			return 0
+		}
+	}
+	_, aStr := a.(string)
+	_, bStr := b.(string)
+	if !aStr && !bStr {
+		na, errA := numberValue(a)
+		nb, errB := numberValue(b)
+		if errA == nil && errB == nil {
+			switch {
+			case na < nb:
+				return -1
+			case na > nb:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
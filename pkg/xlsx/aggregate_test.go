@@ -0,0 +1,58 @@
+package xlsx
+
+import "testing"
+
+func TestAggregateDecimalSum(t *testing.T) {
+	dec := func(s string) Decimal {
+		d, err := ParseDecimal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	data := []map[string]interface{}{
+		{"sum": dec("0.1"), "mixed": dec("0.1")},
+		{"sum": dec("0.2"), "mixed": 0.2},
+		{"sum": dec("1234.56"), "mixed": nil},
+	}
+	rows, err := Aggregate(data, nil, []SummaryField{
+		{Field: "sum", Func: AggSum},
+		{Field: "mixed", Func: AggSum},
+	}, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rows[0].Values[0], dec("1234.86"); got != want {
+		t.Errorf("сумма Decimal = %v (%T), ожидалось %v", got, got, want)
+	}
+	if got, ok := rows[0].Values[1].(float64); !ok || got < 0.29 || got > 0.31 {
+		t.Errorf("сумма смешанных значений = %v (%T), ожидалось float64 0.3", rows[0].Values[1], rows[0].Values[1])
+	}
+}
+
+func TestDecimalAdd(t *testing.T) {
+	tests := []struct {
+		a, b, want string
+	}{
+		{"0.1", "0.2", "0.3"},
+		{"1234.5", "-0.25", "1234.25"},
+		{"-1", "1", "0"},
+		{"999999999999999.99", "0.01", "1000000000000000"},
+	}
+	for _, tt := range tests {
+		a, _ := ParseDecimal(tt.a)
+		b, _ := ParseDecimal(tt.b)
+		got, err := a.Add(b)
+		if err != nil {
+			t.Errorf("%s + %s: %v", tt.a, tt.b, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("%s + %s = %s, ожидалось %s", tt.a, tt.b, got, tt.want)
+		}
+	}
+	a, _ := ParseDecimal("9223372036854775807")
+	if _, err := a.Add(a); err == nil {
+		t.Error("ожидалась ошибка переполнения")
+	}
+}
@@ -0,0 +1,114 @@
+package xlsx
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+)
+
+// ReadJob задание чтения группы листов книги с одинаковой структурой.
+// Листы выбираются по именам, шаблону (glob), регулярному выражению или все сразу.
+type ReadJob struct {
+	Sheets    []string `json:"sheets,omitempty"`     // имена листов
+	Glob      string   `json:"glob,omitempty"`       // шаблон имени листа, например "Филиал*"
+	Regex     string   `json:"regex,omitempty"`      // регулярное выражение имени листа
+	AllSheets bool     `json:"all_sheets,omitempty"` // все листы книги
+
+	StartRow     int                `json:"start_row,omitempty"`
+	MatchHeaders bool               `json:"match_headers,omitempty"` // искать колонки по заголовкам полей (header, aliases)
+	HeaderRow    int                `json:"header_row,omitempty"`    // номер строки заголовка (0 - искать автоматически)
+	SheetField   string             `json:"sheet_field,omitempty"`   // поле записи, в которое пишется имя листа-источника
+	Value        string             `json:"value,omitempty"`         // что читать из ячеек: raw (по умолчанию), formatted, both
+	Fields       map[int]FieldExcel `json:"fields"`
+}
+
+// SheetNames листы книги, подходящие под задание (в порядке листов книги)
+func (j ReadJob) SheetNames(wb *Workbook) ([]string, error) {
+	var re *regexp.Regexp
+	if j.Regex != "" {
+		var err error
+		if re, err = regexp.Compile(j.Regex); err != nil {
+			return nil, fmt.Errorf("regex %q: %w", j.Regex, err)
+		}
+	}
+	if j.Glob != "" {
+		if _, err := path.Match(j.Glob, ""); err != nil {
+			return nil, fmt.Errorf("glob %q: %w", j.Glob, err)
+		}
+	}
+	listed := make(map[string]bool, len(j.Sheets))
+	for _, name := range j.Sheets {
+		if index, _ := wb.f.GetSheetIndex(name); index == -1 {
+			return nil, fmt.Errorf("лист %s не найден в файле %s", name, wb.filename)
+		}
+		listed[name] = true
+	}
+
+	var names []string
+	for _, name := range wb.f.GetSheetList() {
+		match := j.AllSheets || listed[name] || (re != nil && re.MatchString(name))
+		if !match && j.Glob != "" {
+			match, _ = path.Match(j.Glob, name)
+		}
+		if match {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("в файле %s нет листов для задания (sheets %v, glob %q, regex %q)", wb.filename, j.Sheets, j.Glob, j.Regex)
+	}
+	return names, nil
+}
+
+// ReadJobs читаем листы книги по заданиям jobs. Результат - записи по именам листов,
+// sheets - имена прочитанных листов по порядку.
+func ReadJobs(wb *Workbook, jobs []ReadJob, log Logger) (data map[string][]map[string]interface{}, sheets []string, err error) {
+	data, sheets, _, err = readJobs(wb, jobs, log, false)
+	return data, sheets, err
+}
+
+// ReadJobsValidate чтение по заданиям с проверкой всех строк (см. ExcelToDataValidate)
+func ReadJobsValidate(wb *Workbook, jobs []ReadJob, log Logger) (map[string][]map[string]interface{}, []string, CellErrors, error) {
+	return readJobs(wb, jobs, log, true)
+}
+
+func readJobs(wb *Workbook, jobs []ReadJob, log Logger, collect bool) (map[string][]map[string]interface{}, []string, CellErrors, error) {
+	var (
+		sheets []string
+		errs   CellErrors
+	)
+	data := make(map[string][]map[string]interface{})
+	for i, job := range jobs {
+		names, err := job.SheetNames(wb)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("задание %d: %w", i+1, err)
+		}
+		for _, sheet := range names {
+			if _, ok := data[sheet]; ok {
+				return nil, nil, nil, fmt.Errorf("задание %d: лист %s уже прочитан другим заданием", i+1, sheet)
+			}
+			fe := NewFieldsExcel(sheet, job.Fields, log)
+			if job.MatchHeaders {
+				fe.UseHeaders(job.HeaderRow)
+			}
+			fe.SetValueMode(job.Value)
+			rr, err := fe.SheetRows(wb, job.StartRow)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("лист %s: %w", sheet, err)
+			}
+			records, sheetErrs, err := fe.readData(rr, collect)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("лист %s: %w", sheet, err)
+			}
+			if job.SheetField != "" {
+				for _, rec := range records {
+					rec[job.SheetField] = sheet
+				}
+			}
+			data[sheet] = records
+			sheets = append(sheets, sheet)
+			errs = append(errs, sheetErrs...)
+		}
+	}
+	return data, sheets, errs, nil
+}
@@ -0,0 +1,113 @@
+package xlsx
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// writeBranches книга с листами филиалов, кварталов и итогов
+func writeBranches(t *testing.T) *Workbook {
+	t.Helper()
+	f := excelize.NewFile()
+	sheets := map[string][][]interface{}{
+		"Филиал Москва": {{"Ф.И.О.", "Сумма"}, {"Иванов", 10}, {"Петров", 20}},
+		"Итоги":         {{"Всего", 60}},
+		"Филиал Тверь":  {{"Ф.И.О.", "Сумма"}, {"Сидоров", 30}},
+		"Q1-2023":       {{"Месяц", "План"}, {"Январь", 100}},
+		"Q2-2023":       {{"Месяц", "План"}, {"Апрель", 200}},
+	}
+	for _, name := range []string{"Филиал Москва", "Итоги", "Филиал Тверь", "Q1-2023", "Q2-2023"} {
+		if _, err := f.NewSheet(name); err != nil {
+			t.Fatal(err)
+		}
+		for r, row := range sheets[name] {
+			cell, _ := excelize.CoordinatesToCellName(1, r+1)
+			if err := f.SetSheetRow(name, cell, &row); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	f.DeleteSheet("Sheet1")
+	filename := filepath.Join(t.TempDir(), "branches.xlsx")
+	if err := f.SaveAs(filename); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	wb, err := OpenWorkbook(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { wb.Close() })
+	return wb
+}
+
+func TestReadJobSheetNames(t *testing.T) {
+	wb := writeBranches(t)
+	tests := []struct {
+		name string
+		job  ReadJob
+		want []string
+		err  string
+	}{
+		{name: "glob", job: ReadJob{Glob: "Филиал*"}, want: []string{"Филиал Москва", "Филиал Тверь"}},
+		{name: "regex", job: ReadJob{Regex: `^Q\d-2023$`}, want: []string{"Q1-2023", "Q2-2023"}},
+		{name: "sheets и glob", job: ReadJob{Sheets: []string{"Итоги"}, Glob: "Q1*"}, want: []string{"Итоги", "Q1-2023"}},
+		{name: "все листы", job: ReadJob{AllSheets: true}, want: []string{"Филиал Москва", "Итоги", "Филиал Тверь", "Q1-2023", "Q2-2023"}},
+		{name: "нет листа", job: ReadJob{Sheets: []string{"Филиал Тула"}}, err: "Филиал Тула"},
+		{name: "нет совпадений", job: ReadJob{Glob: "Склад*"}, err: "нет листов"},
+		{name: "ошибка regex", job: ReadJob{Regex: "Q("}, err: "regex"},
+		{name: "ошибка glob", job: ReadJob{Glob: "Q["}, err: "glob"},
+	}
+	for _, tt := range tests {
+		got, err := tt.job.SheetNames(wb)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%v: ошибка %v, ожидалось %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: листы %q (%v), ожидалось %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestReadJobs(t *testing.T) {
+	wb := writeBranches(t)
+	jobs := []ReadJob{
+		{
+			Glob:       "Филиал*",
+			SheetField: "branch",
+			Fields:     map[int]FieldExcel{1: {Name: "fio"}, 2: {Name: "sum", Type: "int64"}},
+		},
+		{
+			Regex:  `^Q\d-2023$`,
+			Fields: map[int]FieldExcel{1: {Name: "month"}, 2: {Name: "plan", Type: "int64"}},
+		},
+	}
+
+	data, sheets, err := ReadJobs(wb, jobs, nopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Филиал Москва", "Филиал Тверь", "Q1-2023", "Q2-2023"}; !reflect.DeepEqual(sheets, want) {
+		t.Errorf("прочитаны листы %q, ожидалось %q", sheets, want)
+	}
+	tver := data["Филиал Тверь"]
+	if len(tver) != 1 || tver[0]["fio"] != "Сидоров" || tver[0]["sum"] != int64(30) || tver[0]["branch"] != "Филиал Тверь" {
+		t.Errorf("Филиал Тверь: %v", tver)
+	}
+	if q2 := data["Q2-2023"]; len(q2) != 1 || q2[0]["month"] != "Апрель" || q2[0]["plan"] != int64(200) {
+		t.Errorf("Q2-2023: %v", q2)
+	}
+
+	jobs = append(jobs, ReadJob{Sheets: []string{"Q1-2023"}, Fields: jobs[1].Fields})
+	if _, _, err = ReadJobs(wb, jobs, nopLogger{}); err == nil || !strings.Contains(err.Error(), "уже прочитан") {
+		t.Errorf("лист в двух заданиях: ошибка %v", err)
+	}
+}
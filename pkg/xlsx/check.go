@@ -0,0 +1,129 @@
+package xlsx
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// checkTime момент для проверки формата разбора дат: все элементы формата различимы
+var checkTime = time.Date(2017, time.November, 28, 21, 37, 49, 0, time.UTC)
+
+// CheckFields проверка описания полей: номера колонок, имена, типы, форматы и правила проверки.
+// Возвращает все найденные ошибки по порядку колонок.
+func CheckFields(fields map[int]FieldExcel) []error {
+	keys := make([]int, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+
+	var errs []error
+	names := make(map[string]int, len(fields))
+	for _, key := range keys {
+		v := fields[key]
+		fail := func(format string, args ...interface{}) {
+			errs = append(errs, fmt.Errorf("поле %d: %s", key, fmt.Sprintf(format, args...)))
+		}
+		if key <= 0 {
+			fail("номер колонки должен быть больше 0")
+		}
+		if v.Name == "" {
+			fail("не задано имя (name)")
+		} else if prev, ok := names[v.Name]; ok {
+			fail("имя %q уже задано для поля %d", v.Name, prev)
+		} else {
+			names[v.Name] = key
+		}
+		if v.Width < 0 {
+			fail("отрицательная ширина колонки %v", v.Width)
+		}
+		if v.MaxLength < 0 {
+			fail("отрицательная max_length %v", v.MaxLength)
+		}
+
+		if !isFieldType(v.Type) {
+			fail("неизвестный тип %q (допустимы: %s)", v.Type, strings.Join(FieldTypes(), ", "))
+			continue
+		}
+		if v.Format != "" {
+			nf, err := ParseNumFormat(v.Format)
+			if err != nil {
+				fail("%v", err)
+				continue
+			}
+			date := nf.IsDate()
+			switch {
+			case isDateType(v.Type) && !date:
+				fail("формат %q не является форматом даты или времени", v.Format)
+			case isNumberType(v.Type) && date:
+				fail("формат даты %q у числового поля", v.Format)
+			}
+		}
+		if v.ParseFormat != "" {
+			if !isDateType(v.Type) {
+				fail("формат разбора (parse) задаётся только для дат и времени")
+			} else if err := checkLayout(v.ParseFormat); err != nil {
+				fail("формат разбора %q: %v", v.ParseFormat, err)
+			}
+		}
+		if len(v.ParseFormats) > 0 || v.TimeZone != "" {
+			if !isDateType(v.Type) {
+				fail("parse_formats и time_zone задаются только для дат и времени")
+			}
+			for _, f := range v.ParseFormats {
+				if err := checkLayout(toLayout(f)); err != nil {
+					fail("формат разбора %q: %v", f, err)
+				}
+			}
+			if _, err := location(v.TimeZone); err != nil {
+				fail("часовой пояс %q: %v", v.TimeZone, err)
+			}
+		}
+		if len(v.True) > 0 || len(v.False) > 0 {
+			if v.Type != "bool" {
+				fail("слова true/false задаются только для типа bool")
+			}
+			for _, w := range v.True {
+				for _, f := range v.False {
+					if strings.EqualFold(strings.TrimSpace(w), strings.TrimSpace(f)) {
+						fail("слово %q задано и в true, и в false", w)
+					}
+				}
+			}
+		}
+		if !IsValueMode(v.Value) {
+			fail("неизвестный режим чтения value %q (допустимы: raw, formatted, both)", v.Value)
+		} else if v.Value == ValueFormatted && !v.IsText() {
+			fail("value formatted задаётся только для текстовых полей, для типа %s - both", v.Type)
+		}
+		if (v.Type == "bool" || v.Type == "duration") && (v.Min != "" || v.Max != "") {
+			fail("min/max не задаются для типа %s", v.Type)
+		}
+		if v.hasRules() {
+			if _, err := (FieldsExcel{fields: map[int]FieldExcel{key: v}}).newRules(); err != nil {
+				fail("%s", strings.TrimPrefix(err.Error(), "поле "+v.Name+": "))
+			}
+		}
+	}
+	return errs
+}
+
+// isFieldType допустимый (зарегистрированный) тип поля, регистр учитывается
+func isFieldType(typ string) bool {
+	_, ok := converter(typ)
+	return ok
+}
+
+// checkLayout формат даты Go должен содержать элементы даты и разбирать собственный результат
+func checkLayout(layout string) error {
+	text := checkTime.Format(layout)
+	if text == layout {
+		return fmt.Errorf("нет элементов даты (пример формата: 02.01.2006)")
+	}
+	if _, err := time.Parse(layout, text); err != nil {
+		return err
+	}
+	return nil
+}
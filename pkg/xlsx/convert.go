@@ -0,0 +1,259 @@
+package xlsx
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Converter преобразования значений поля своего типа (FieldExcel.Type).
+// Встроенные типы (int64, float64, date ...) зарегистрированы так же, как пользовательские.
+type Converter struct {
+	// Parse значение ячейки (текст, числа и даты Excel - серийным номером) в значение записи.
+	// Пустая ячейка передаётся только текстовым типам (Text), для остальных значение записи - nil.
+	Parse func(v FieldExcel, cell string) (interface{}, error)
+	// Format значение записи в значение ячейки: string, int64, float64, bool, time.Time, time.Duration, Decimal.
+	// nil - значение записи выводится как есть.
+	Format func(v FieldExcel, val interface{}) (interface{}, error)
+	// Validate проверка значения записи: после Parse при чтении и перед Format при записи (может быть nil)
+	Validate func(v FieldExcel, val interface{}) error
+
+	NumFmt string // формат ячейки по умолчанию (стиль колонки), если у поля не задан format
+	Layout string // формат разбора по умолчанию для дат и времени (тип содержит дату или время)
+	Number bool   // числовой тип: десятичный разделитель CSV, формат вывода не может быть форматом даты
+	Text   bool   // текстовый тип: пустая ячейка - пустая строка, а не nil
+}
+
+// реестр типов полей
+var (
+	convMu     sync.RWMutex
+	converters = make(map[string]Converter)
+	typeNames  []string // имена типов в порядке регистрации
+)
+
+func init() {
+	for _, t := range []struct {
+		name string
+		c    Converter
+	}{
+		{"string", Converter{Parse: parseText, Format: formatText, NumFmt: "@", Text: true}},
+		{"int64", Converter{Parse: parseInt, Format: formatInt, Number: true}},
+		{"float64", Converter{Parse: parseFloat, Format: formatFloat, Number: true}},
+		{"decimal", Converter{Parse: parseDecimalCell, Format: formatDecimal, NumFmt: "#,##0.00", Number: true}},
+		{"currency", Converter{Parse: parseCurrencyCell, Format: formatDecimal, NumFmt: `#,##0.00 "₽"`, Number: true}},
+		{"percent", Converter{Parse: parsePercentCell, Format: formatFloat, NumFmt: "0.00%", Number: true}},
+		{"bool", Converter{Parse: parseBoolCell, Format: formatBool}},
+		{"date", Converter{Parse: parseDateCell, Format: formatDate, NumFmt: "dd.mm.yyyy", Layout: "02.01.2006"}},
+		{"datetime", Converter{Parse: parseDateCell, Format: formatDate, NumFmt: "dd.mm.yyyy hh:mm:ss", Layout: "02.01.2006 15:04:05"}},
+		{"time", Converter{Parse: parseDateCell, Format: formatDate, NumFmt: "hh:mm:ss", Layout: "15:04:05"}},
+		{"duration", Converter{Parse: parseDurationCell, Format: formatDurationValue, NumFmt: "[h]:mm:ss"}},
+	} {
+		if err := RegisterType(t.name, t.c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterType регистрируем тип поля name, после чего его можно указывать в FieldExcel.Type (и в конфиге).
+// Регистрация выполняется до загрузки конфига, обычно в init:
+//
+//	xlsx.RegisterType("inn", xlsx.Converter{
+//		Parse:    func(v xlsx.FieldExcel, cell string) (interface{}, error) { return strings.TrimSpace(cell), nil },
+//		Validate: checkINN,
+//		NumFmt:   "@",
+//	})
+func RegisterType(name string, c Converter) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("RegisterType: пустое имя типа")
+	}
+	if c.Parse == nil {
+		return fmt.Errorf("RegisterType %v: не задана функция Parse", name)
+	}
+	convMu.Lock()
+	defer convMu.Unlock()
+	if _, ok := converters[name]; ok {
+		return fmt.Errorf("RegisterType %v: тип уже зарегистрирован", name)
+	}
+	converters[name] = c
+	typeNames = append(typeNames, name)
+	return nil
+}
+
+// FieldTypes допустимые типы полей в порядке регистрации (пусто - строка)
+func FieldTypes() []string {
+	convMu.RLock()
+	defer convMu.RUnlock()
+	return append([]string(nil), typeNames...)
+}
+
+// converter преобразования для типа поля, пустой тип - текст как есть
+func converter(typ string) (Converter, bool) {
+	if typ == "" {
+		return Converter{Parse: parseText, Text: true}, true
+	}
+	convMu.RLock()
+	defer convMu.RUnlock()
+	c, ok := converters[typ]
+	return c, ok
+}
+
+// parseText текст ячейки как есть
+func parseText(v FieldExcel, cell string) (interface{}, error) {
+	return cell, nil
+}
+
+func formatText(v FieldExcel, val interface{}) (interface{}, error) {
+	if _, ok := val.(string); !ok {
+		val = toString(val, v.ParseFormat)
+	}
+	return val, nil
+}
+
+// parseInt целое число, Excel может хранить целые как 1.5E+5 или 123.0
+func parseInt(v FieldExcel, cell string) (interface{}, error) {
+	cell = strings.TrimSpace(cell)
+	if res, err := strconv.ParseInt(cell, 10, 64); err == nil {
+		return res, nil
+	}
+	f, err := strconv.ParseFloat(cell, 64)
+	if err != nil {
+		return nil, err
+	}
+	if f != math.Trunc(f) {
+		return nil, fmt.Errorf("значение %v не целое", cell)
+	}
+	if f < math.MinInt64 || f >= math.MaxInt64 {
+		return nil, &strconv.NumError{Func: "ParseInt", Num: cell, Err: strconv.ErrRange}
+	}
+	return int64(f), nil
+}
+
+// formatInt целые числа: int64, целые float64 и Decimal (драйверы БД), текст
+func formatInt(v FieldExcel, val interface{}) (interface{}, error) {
+	switch val.(type) {
+	case int64, float64, Decimal, string:
+		return toInt64(val)
+	}
+	return nil, fmt.Errorf("неожиданный тип значения %T", val)
+}
+
+func parseFloat(v FieldExcel, cell string) (interface{}, error) {
+	res, err := strconv.ParseFloat(cell, 64)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// formatFloat дробные числа и проценты (доля): float64, int64 и Decimal (драйверы БД), текст
+func formatFloat(v FieldExcel, val interface{}) (interface{}, error) {
+	switch x := val.(type) {
+	case float64:
+		return x, nil
+	case int64:
+		return float64(x), nil
+	case Decimal:
+		return x.Float64(), nil
+	case string:
+		if v.Type == "percent" {
+			return parsePercent(x)
+		}
+		return strconv.ParseFloat(x, 64)
+	}
+	return nil, fmt.Errorf("неожиданный тип значения %T", val)
+}
+
+func parseDecimalCell(v FieldExcel, cell string) (interface{}, error) {
+	res, err := ParseDecimal(cell)
+	if err != nil {
+		return nil, err
+	}
+	return roundExcel(res), nil
+}
+
+func parseCurrencyCell(v FieldExcel, cell string) (interface{}, error) {
+	res, err := parseAmount(cell)
+	if err != nil {
+		return nil, err
+	}
+	return roundExcel(res), nil
+}
+
+// formatDecimal точные числа и суммы
+func formatDecimal(v FieldExcel, val interface{}) (interface{}, error) {
+	switch x := val.(type) {
+	case Decimal:
+		return x, nil
+	case float64:
+		return DecimalFromFloat(x)
+	case int64:
+		return Decimal{coef: x}, nil
+	case string:
+		if v.Type == "currency" {
+			return parseAmount(x)
+		}
+		return ParseDecimal(x)
+	}
+	return nil, fmt.Errorf("неожиданный тип значения %T", val)
+}
+
+func parsePercentCell(v FieldExcel, cell string) (interface{}, error) {
+	return parsePercent(cell)
+}
+
+func parseBoolCell(v FieldExcel, cell string) (interface{}, error) {
+	return v.parseBool(cell)
+}
+
+func formatBool(v FieldExcel, val interface{}) (interface{}, error) {
+	switch x := val.(type) {
+	case bool:
+		return x, nil
+	case string:
+		return v.parseBool(x)
+	case int64:
+		return x != 0, nil
+	case float64:
+		return x != 0, nil
+	}
+	return nil, fmt.Errorf("неожиданный тип значения %T", val)
+}
+
+// formatDate даты и время: time.Time переводится в часовой пояс поля, текст разбирается как при чтении
+func formatDate(v FieldExcel, val interface{}) (interface{}, error) {
+	switch x := val.(type) {
+	case time.Time:
+		if v.TimeZone == "" {
+			return v.timeValue(x), nil
+		}
+		loc, err := location(v.TimeZone)
+		if err != nil {
+			return nil, err
+		}
+		return v.timeValue(x.In(loc)), nil
+	case string:
+		return parseDateCell(v, x)
+	case float64:
+		return parseDateCell(v, strconv.FormatFloat(x, 'f', -1, 64))
+	}
+	return nil, fmt.Errorf("неожиданный тип значения %T", val)
+}
+
+func parseDurationCell(v FieldExcel, cell string) (interface{}, error) {
+	return parseDuration(cell)
+}
+
+func formatDurationValue(v FieldExcel, val interface{}) (interface{}, error) {
+	switch x := val.(type) {
+	case time.Duration:
+		return x, nil
+	case string:
+		return parseDuration(x)
+	case float64:
+		return daysToDuration(x), nil
+	}
+	return nil, fmt.Errorf("неожиданный тип значения %T", val)
+}
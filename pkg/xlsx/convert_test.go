@@ -0,0 +1,90 @@
+package xlsx
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// registerTestType регистрируем тип на время теста
+func registerTestType(t *testing.T, name string, c Converter) {
+	t.Helper()
+	if err := RegisterType(name, c); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		convMu.Lock()
+		defer convMu.Unlock()
+		delete(converters, name)
+		for i, n := range typeNames {
+			if n == name {
+				typeNames = append(typeNames[:i], typeNames[i+1:]...)
+				break
+			}
+		}
+	})
+}
+
+func TestRegisterType(t *testing.T) {
+	registerTestType(t, "inn", Converter{
+		Parse: func(v FieldExcel, cell string) (interface{}, error) {
+			return strings.TrimSpace(cell), nil
+		},
+		Validate: func(v FieldExcel, val interface{}) error {
+			if s, _ := val.(string); len(s) != 10 && len(s) != 12 {
+				return fmt.Errorf("ИНН должен содержать 10 или 12 цифр")
+			}
+			return nil
+		},
+		NumFmt: "@",
+		Text:   true,
+	})
+
+	if err := RegisterType("inn", Converter{Parse: parseText}); err == nil {
+		t.Error("повторная регистрация типа inn без ошибки")
+	}
+	if err := RegisterType("", Converter{Parse: parseText}); err == nil {
+		t.Error("регистрация типа без имени без ошибки")
+	}
+	if err := RegisterType("snils", Converter{}); err == nil {
+		t.Error("регистрация типа без Parse без ошибки")
+	}
+	if errs := CheckFields(map[int]FieldExcel{1: {Name: "inn", Type: "inn"}}); len(errs) != 0 {
+		t.Errorf("CheckFields: %v", errs)
+	}
+
+	filename := writeBook(t, [][]interface{}{{"ИНН"}, {" 7707083893 "}, {"123"}, {"500100732259"}})
+	fe := newTestFields(map[int]FieldExcel{1: {Name: "inn", Type: "inn"}})
+	data, errs, err := fe.ExcelToDataValidate(filename, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 2 || data[0]["inn"] != "7707083893" || data[1]["inn"] != "500100732259" {
+		t.Errorf("прочитано: %v", data)
+	}
+	if len(errs) != 1 || errs[0].Row != 3 || errs[0].Type != "inn" || !strings.Contains(errs[0].Reason, "10 или 12") {
+		t.Errorf("ошибки: %v", errs)
+	}
+
+	out := filepath.Join(t.TempDir(), "out.xlsx")
+	fw := NewFieldsExcel("Вывод", map[int]FieldExcel{1: {Name: "inn", Header: "ИНН", Type: "inn"}}, nopLogger{})
+	errs, err = fw.DataToExcelValidate(out, 1, []map[string]interface{}{{"inn": "7707083893"}, {"inn": "1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 1 || errs[0].Row != 2 {
+		t.Errorf("ошибки записи: %v", errs)
+	}
+	f, err := excelize.OpenFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, _ := f.GetRows("Вывод")
+	if len(rows) != 2 || rows[1][0] != "7707083893" {
+		t.Errorf("лист Вывод: %q", rows)
+	}
+}
@@ -0,0 +1,205 @@
+package xlsx
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// CopySheets копирует листы sheets из Excel-файла src в файл dst (файл создаётся при отсутствии,
+// одноимённые листы заменяются). Переносятся значения (формулы - вычисленными значениями),
+// числовые форматы ячеек, ширина колонок и объединённые ячейки. Файл dst сохраняется с настройками opts.
+func CopySheets(src, dst string, sheets []string, opts SaveOptions) error {
+	ws, err := OpenWorkbook(src)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	wd, err := OpenOrNewWorkbook(dst)
+	if err != nil {
+		return err
+	}
+	defer wd.Close()
+	wd.SetSaveOptions(opts)
+
+	if err = wd.CopySheets(ws, sheets); err != nil {
+		return err
+	}
+	return wd.Save()
+}
+
+// CopySheets копирует листы sheets из открытой книги src (см. CopySheets)
+func (wb *Workbook) CopySheets(src *Workbook, sheets []string) error {
+	fs, fd := src.f, wb.f
+	styles := make(map[int]int) // стиль в src -> стиль в dst
+	for _, sheet := range sheets {
+		if index, _ := fs.GetSheetIndex(sheet); index == -1 {
+			return fmt.Errorf("лист %s не найден в файле %s", sheet, src.filename)
+		}
+		if err := wb.newSheet(sheet); err != nil {
+			return err
+		}
+		if err := copySheet(fs, fd, sheet, styles); err != nil {
+			return fmt.Errorf("лист %s: %w", sheet, err)
+		}
+	}
+	fd.SetActiveSheet(0)
+	return nil
+}
+
+// copySheet копирует содержимое листа sheet из fs в fd
+func copySheet(fs, fd *excelize.File, sheet string, styles map[int]int) error {
+	sw, err := fd.NewStreamWriter(sheet)
+	if err != nil {
+		return fmt.Errorf("NewStreamWriter %v", err)
+	}
+
+	maxColumn, err := sheetMaxColumn(fs, sheet)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < maxColumn; i++ { // ширину колонок задаём до записи строк
+		name, _ := excelize.ColumnNumberToName(i + 1)
+		width, err := fs.GetColWidth(sheet, name)
+		if err != nil {
+			return fmt.Errorf("GetColWidth %v", err)
+		}
+		if err = sw.SetColWidth(i+1, i+1, width); err != nil {
+			return fmt.Errorf("SetColWidth %v", err)
+		}
+	}
+
+	rows, err := fs.Rows(sheet)
+	if err != nil {
+		return fmt.Errorf("f.Rows %v", err)
+	}
+	defer rows.Close()
+	rowNum := 0
+	for rows.Next() {
+		rowNum++
+		row, err := rows.Columns(excelize.Options{RawCellValue: true})
+		if err != nil {
+			return fmt.Errorf("rows.Columns %v", err)
+		}
+		if len(row) == 0 {
+			continue
+		}
+		rowVal := make([]interface{}, len(row))
+		for i, raw := range row {
+			cell, _ := excelize.CoordinatesToCellName(i+1, rowNum)
+			val, err := copyCellValue(fs, sheet, cell, raw)
+			if err != nil {
+				return err
+			}
+			styleID, err := copyCellStyle(fs, fd, sheet, cell, styles)
+			if err != nil {
+				return err
+			}
+			rowVal[i] = excelize.Cell{StyleID: styleID, Value: val}
+		}
+		addr, _ := excelize.CoordinatesToCellName(1, rowNum)
+		if err = sw.SetRow(addr, rowVal); err != nil {
+			return fmt.Errorf("SetRow %v", err)
+		}
+	}
+
+	merged, err := fs.GetMergeCells(sheet)
+	if err != nil {
+		return fmt.Errorf("GetMergeCells %v", err)
+	}
+	for _, mc := range merged {
+		if err = sw.MergeCell(mc.GetStartAxis(), mc.GetEndAxis()); err != nil {
+			return fmt.Errorf("MergeCell %v", err)
+		}
+	}
+	return sw.Flush()
+}
+
+// sheetMaxColumn кол-во колонок листа
+func sheetMaxColumn(f *excelize.File, sheet string) (int, error) {
+	rows, err := f.Rows(sheet)
+	if err != nil {
+		return 0, fmt.Errorf("f.Rows %v", err)
+	}
+	defer rows.Close()
+	max := 0
+	for rows.Next() {
+		row, err := rows.Columns(excelize.Options{RawCellValue: true})
+		if err != nil {
+			return 0, fmt.Errorf("rows.Columns %v", err)
+		}
+		if len(row) > max {
+			max = len(row)
+		}
+	}
+	return max, nil
+}
+
+// copyCellValue значение ячейки с учётом её типа
+func copyCellValue(fs *excelize.File, sheet, cell, raw string) (interface{}, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	typ, err := fs.GetCellType(sheet, cell)
+	if err != nil {
+		return nil, fmt.Errorf("GetCellType %v", err)
+	}
+	switch typ {
+	case excelize.CellTypeBool:
+		return raw == "1" || raw == "TRUE", nil
+	case excelize.CellTypeNumber, excelize.CellTypeUnset: // строки, в том числе результат формулы (t="str"), не разбираем
+		if num, err := strconv.ParseFloat(raw, 64); err == nil {
+			return num, nil
+		}
+	}
+	return raw, nil
+}
+
+// copyCellStyle переносим числовой формат ячейки, возвращаем стиль в fd
+func copyCellStyle(fs, fd *excelize.File, sheet, cell string, styles map[int]int) (int, error) {
+	srcStyle, err := fs.GetCellStyle(sheet, cell)
+	if err != nil {
+		return 0, fmt.Errorf("GetCellStyle %v", err)
+	}
+	if srcStyle == 0 {
+		return 0, nil
+	}
+	if style, ok := styles[srcStyle]; ok {
+		return style, nil
+	}
+	numFmtID, code := cellNumFmt(fs, srcStyle)
+	style := 0
+	switch {
+	case code != "":
+		style, err = fd.NewStyle(&excelize.Style{CustomNumFmt: &code})
+	case numFmtID > 0:
+		style, err = fd.NewStyle(&excelize.Style{NumFmt: numFmtID})
+	}
+	if err != nil {
+		return 0, err
+	}
+	styles[srcStyle] = style
+	return style, nil
+}
+
+// cellNumFmt код числового формата стиля: встроенный номер или пользовательский формат
+func cellNumFmt(f *excelize.File, styleID int) (int, string) {
+	if f.Styles == nil || f.Styles.CellXfs == nil || styleID >= len(f.Styles.CellXfs.Xf) {
+		return 0, ""
+	}
+	xf := f.Styles.CellXfs.Xf[styleID]
+	if xf.NumFmtID == nil {
+		return 0, ""
+	}
+	numFmtID := *xf.NumFmtID
+	if f.Styles.NumFmts != nil {
+		for _, nf := range f.Styles.NumFmts.NumFmt {
+			if nf.NumFmtID == numFmtID {
+				return numFmtID, nf.FormatCode
+			}
+		}
+	}
+	return numFmtID, ""
+}
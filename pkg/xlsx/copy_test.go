@@ -0,0 +1,87 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// replacePart заменяем часть name в zip-архиве книги filename
+func replacePart(t *testing.T, filename, name, content string) {
+	t.Helper()
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	out, err := os.Create(filename + ".tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(out)
+	for _, zf := range zr.File {
+		w, err := zw.Create(zf.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if zf.Name == name {
+			_, err = io.WriteString(w, content)
+		} else {
+			var rc io.ReadCloser
+			if rc, err = zf.Open(); err == nil {
+				_, err = io.Copy(w, rc)
+				rc.Close()
+			}
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = out.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Rename(filename+".tmp", filename); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCopySheetsCellTypes(t *testing.T) {
+	src := writeBook(t, [][]interface{}{{"x"}})
+	// A1 - формула со строковым результатом (t="str"), B1 - число, C1 - логическое значение
+	replacePart(t, src, "xl/worksheets/sheet1.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`+
+		`<row r="1"><c r="A1" t="str"><f>"00"&amp;"123"</f><v>00123</v></c><c r="B1"><v>42</v></c><c r="C1" t="b"><v>1</v></c></row>`+
+		`</sheetData></worksheet>`)
+
+	dst := filepath.Join(t.TempDir(), "copy.xlsx")
+	if err := CopySheets(src, dst, []string{"Лист1"}, SaveOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := excelize.OpenFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for cell, want := range map[string]struct {
+		value string
+		typ   excelize.CellType
+	}{
+		"A1": {"00123", excelize.CellTypeInlineString},
+		"B1": {"42", excelize.CellTypeUnset},
+		"C1": {"TRUE", excelize.CellTypeBool},
+	} {
+		value, _ := f.GetCellValue("Лист1", cell)
+		typ, _ := f.GetCellType("Лист1", cell)
+		if value != want.value || typ != want.typ {
+			t.Errorf("%v = %q (тип %v), ожидалось %q (тип %v)", cell, value, typ, want.value, want.typ)
+		}
+	}
+}
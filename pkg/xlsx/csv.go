@@ -0,0 +1,386 @@
+package xlsx
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
+)
+
+// Форматы файлов
+const (
+	FormatXLSX = "xlsx"
+	FormatXLS  = "xls"
+	FormatCSV  = "csv"
+	FormatTSV  = "tsv"
+
+	FormatJSON   = "json"   // массив объектов
+	FormatNDJSON = "ndjson" // JSON Lines: объект на строку
+)
+
+// CSVOptions настройки чтения и записи CSV/TSV-файлов
+type CSVOptions struct {
+	Delimiter string `json:"delimiter,omitempty"` // разделитель полей (по умолчанию ";" для csv, табуляция для tsv)
+	Quote     string `json:"quote,omitempty"`     // символ кавычек (по умолчанию ", "none" - без кавычек)
+	Encoding  string `json:"encoding,omitempty"`  // кодировка: utf-8 (по умолчанию), windows-1251, cp866, koi8-r
+	Decimal   string `json:"decimal,omitempty"`   // десятичный разделитель чисел (по умолчанию ".")
+	NoHeader  bool   `json:"no_header,omitempty"` // в файле нет строки заголовка
+	AddBOM    bool   `json:"add_bom,omitempty"`   // записывать BOM в начало UTF-8 файла (для Excel)
+}
+
+// SetFormat задаёт формат файла явно (xlsx, xls, csv, tsv, json, ndjson), по умолчанию формат определяется по расширению
+func (s *FieldsExcel) SetFormat(format string) {
+	s.format = strings.ToLower(strings.TrimSpace(format))
+}
+
+// SetCSVOptions задаёт настройки чтения и записи CSV/TSV-файлов
+func (s *FieldsExcel) SetCSVOptions(opts CSVOptions) {
+	s.csv = opts
+}
+
+// fileFormat формат файла: заданный явно или по расширению
+func (s FieldsExcel) fileFormat(filename string) string {
+	if s.format != "" {
+		return s.format
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV
+	case ".tsv", ".tab":
+		return FormatTSV
+	case ".xls":
+		return FormatXLS
+	case ".json":
+		return FormatJSON
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON
+	}
+	return FormatXLSX
+}
+
+// isCSV файл в формате CSV или TSV
+func (s FieldsExcel) isCSV(filename string) bool {
+	format := s.fileFormat(filename)
+	return format == FormatCSV || format == FormatTSV
+}
+
+// csvComma разделитель полей
+func (s FieldsExcel) csvComma(filename string) rune {
+	if s.csv.Delimiter != "" {
+		if s.csv.Delimiter == `\t` {
+			return '\t'
+		}
+		r, _ := utf8.DecodeRuneInString(s.csv.Delimiter)
+		return r
+	}
+	if s.fileFormat(filename) == FormatTSV {
+		return '\t'
+	}
+	return ';'
+}
+
+// csvQuote символ кавычек (0 - без кавычек)
+func (s FieldsExcel) csvQuote() rune {
+	switch s.csv.Quote {
+	case "":
+		return '"'
+	case "none":
+		return 0
+	}
+	r, _ := utf8.DecodeRuneInString(s.csv.Quote)
+	return r
+}
+
+// csvEncoding кодировка файла (nil - UTF-8)
+func (s FieldsExcel) csvEncoding() (encoding.Encoding, error) {
+	switch strings.ToLower(strings.ReplaceAll(s.csv.Encoding, "_", "-")) {
+	case "", "utf-8", "utf8":
+		return nil, nil
+	case "windows-1251", "cp1251", "win1251":
+		return charmap.Windows1251, nil
+	case "cp866", "ibm866", "dos":
+		return charmap.CodePage866, nil
+	case "koi8-r", "koi8r":
+		return charmap.KOI8R, nil
+	}
+	return nil, fmt.Errorf("неизвестная кодировка %q", s.csv.Encoding)
+}
+
+// normalizeNumber приводим число из текста к виду для разбора (десятичный разделитель, пробелы)
+func (s FieldsExcel) normalizeNumber(v FieldExcel, cell string) string {
+	if s.csv.Decimal == "" || s.csv.Decimal == "." || !isNumberType(v.Type) {
+		return cell
+	}
+	cell = strings.NewReplacer(" ", "", "\u00a0", "").Replace(cell)
+	return strings.Replace(cell, s.csv.Decimal, ".", 1)
+}
+
+// csvSource строки CSV-файла
+type csvSource struct {
+	file   *os.File
+	r      *csvReader
+	record []string
+	err    error
+}
+
+// openCSV открываем CSV-файл для чтения
+func (s *FieldsExcel) openCSV(filename string) (*csvSource, error) {
+	enc, err := s.csvEncoding()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	if s.sheetName == "" {
+		s.sheetName = filepath.Base(filename)
+	}
+	var r io.Reader = file
+	if enc != nil {
+		r = transform.NewReader(file, enc.NewDecoder())
+	}
+	br := bufio.NewReader(r)
+	if enc == nil { // пропускаем BOM
+		if ch, _, err := br.ReadRune(); err == nil && ch != '\ufeff' {
+			br.UnreadRune()
+		}
+	}
+	return &csvSource{file: file, r: &csvReader{r: br, comma: s.csvComma(filename), quote: s.csvQuote()}}, nil
+}
+
+func (src *csvSource) Next() bool {
+	src.record, src.err = src.r.Read()
+	return src.err != io.EOF
+}
+
+func (src *csvSource) Columns() ([]string, error) {
+	if src.err != nil {
+		return nil, fmt.Errorf("строка %d: %w", src.r.line, src.err)
+	}
+	for _, v := range src.record {
+		if v != "" {
+			return src.record, nil
+		}
+	}
+	return nil, nil // пустая строка
+}
+
+func (src *csvSource) Close() error {
+	return src.file.Close()
+}
+
+// csvReader разбор CSV с произвольными разделителем и кавычками
+type csvReader struct {
+	r     *bufio.Reader
+	comma rune
+	quote rune // 0 - без кавычек
+	line  int
+}
+
+// Read читаем одну запись, io.EOF - данных больше нет
+func (c *csvReader) Read() ([]string, error) {
+	var (
+		fields   []string
+		field    strings.Builder
+		inQuotes bool
+		started  bool
+	)
+	c.line++
+	for {
+		r, _, err := c.r.ReadRune()
+		if err == io.EOF {
+			if inQuotes {
+				return nil, fmt.Errorf("не закрыта кавычка")
+			}
+			if !started {
+				return nil, io.EOF
+			}
+			return append(fields, field.String()), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		started = true
+
+		switch {
+		case inQuotes:
+			if r == c.quote {
+				next, _, err := c.r.ReadRune()
+				if err == nil && next == c.quote { // удвоенная кавычка внутри значения
+					field.WriteRune(r)
+					continue
+				}
+				if err == nil {
+					c.r.UnreadRune()
+				}
+				inQuotes = false
+				continue
+			}
+			if r == '\n' {
+				c.line++
+			}
+			field.WriteRune(r)
+		case c.quote != 0 && r == c.quote && field.Len() == 0:
+			inQuotes = true
+		case r == c.comma:
+			fields = append(fields, field.String())
+			field.Reset()
+		case r == '\r':
+			if next, _, err := c.r.ReadRune(); err == nil && next != '\n' {
+				c.r.UnreadRune()
+			}
+			return append(fields, field.String()), nil
+		case r == '\n':
+			return append(fields, field.String()), nil
+		default:
+			field.WriteRune(r)
+		}
+	}
+}
+
+// csvWriter запись CSV с произвольными разделителем и кавычками
+type csvWriter struct {
+	w     *bufio.Writer
+	comma rune
+	quote rune // 0 - без кавычек
+}
+
+// Write записываем одну запись
+func (c *csvWriter) Write(fields []string) error {
+	for i, field := range fields {
+		if i > 0 {
+			c.w.WriteRune(c.comma)
+		}
+		if c.quote != 0 && strings.ContainsAny(field, string([]rune{c.comma, c.quote, '\r', '\n'})) {
+			q := string(c.quote)
+			field = q + strings.ReplaceAll(field, q, q+q) + q
+		}
+		if _, err := c.w.WriteString(field); err != nil {
+			return err
+		}
+	}
+	_, err := c.w.WriteString("\r\n")
+	return err
+}
+
+// dataToCSV запись данных в CSV-файл, collect - собирать ошибки ячеек вместо прерывания записи
+func (s *FieldsExcel) dataToCSV(filename string, next NextRecord, collect bool) (CellErrors, error) {
+	enc, err := s.csvEncoding()
+	if err != nil {
+		return nil, err
+	}
+	if s.sheetName == "" {
+		s.sheetName = filepath.Base(filename)
+	}
+	var errs CellErrors
+	err = writeFileAtomic(filename, s.save, func(file io.Writer) error {
+		w := file
+		var tw io.WriteCloser
+		if enc != nil {
+			tw = transform.NewWriter(file, encoding.ReplaceUnsupported(enc.NewEncoder()))
+			w = tw
+		} else if s.csv.AddBOM {
+			if _, err := io.WriteString(file, "\ufeff"); err != nil {
+				return err
+			}
+		}
+		bw := bufio.NewWriter(w)
+		cw := &csvWriter{w: bw, comma: s.csvComma(filename), quote: s.csvQuote()}
+
+		maxColumn := s.MaxColumn()
+		if !s.csv.NoHeader {
+			header := make([]string, maxColumn)
+			for i := 0; i < maxColumn; i++ {
+				header[i] = s.fields[i+1].Header
+			}
+			if err := cw.Write(header); err != nil {
+				return err
+			}
+		}
+
+		line := make([]string, maxColumn)
+		for r := 0; ; r++ {
+			row, err := next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			rowVal, rowErrs := s.recordToRow(row, maxColumn, r+1)
+			if len(rowErrs) > 0 {
+				if !collect {
+					return rowErrs
+				}
+				errs = append(errs, rowErrs...) // строку с ошибками пропускаем
+				continue
+			}
+			for i, cell := range rowVal {
+				line[i] = ""
+				if c, ok := cell.(excelize.Cell); ok {
+					line[i] = s.formatCSVValue(s.fields[i+1], c.Value)
+				}
+			}
+			if err := cw.Write(line); err != nil {
+				return err
+			}
+		}
+
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		if tw != nil {
+			return tw.Close()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
+
+// formatCSVValue значение для записи в CSV
+func (s FieldsExcel) formatCSVValue(v FieldExcel, val interface{}) string {
+	switch x := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case time.Time:
+		if v.ParseFormat == "" {
+			return x.Format("02.01.2006")
+		}
+		return x.Format(v.ParseFormat)
+	case float64:
+		return s.formatNumber(strconv.FormatFloat(x, 'f', -1, 64))
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case Decimal:
+		return s.formatNumber(x.String())
+	case bool:
+		return v.boolText(x)
+	case time.Duration:
+		return FormatDuration(x)
+	}
+	return fmt.Sprint(val)
+}
+
+// formatNumber заменяем десятичный разделитель
+func (s FieldsExcel) formatNumber(num string) string {
+	if s.csv.Decimal == "" || s.csv.Decimal == "." {
+		return num
+	}
+	return strings.Replace(num, ".", s.csv.Decimal, 1)
+}
@@ -0,0 +1,58 @@
+package xlsx
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestCSVRoundTrip(t *testing.T) {
+	fields := map[int]FieldExcel{
+		1: {Name: "name", Header: "Наименование"},
+		2: {Name: "sum", Header: "Сумма", Type: "float64"},
+		3: {Name: "note", Header: "Примечание"},
+	}
+	opts := CSVOptions{Encoding: "windows-1251", Decimal: ","}
+	data := []map[string]interface{}{
+		{"name": `ООО "Рога и копыта"`, "sum": 1234.5, "note": "первая; вторая"},
+		{"name": "Иванов", "sum": 7.0, "note": "строка 1\nстрока 2"},
+	}
+	filename := filepath.Join(t.TempDir(), "data.csv")
+
+	fw := NewFieldsExcel("", fields, nopLogger{})
+	fw.SetCSVOptions(opts)
+	if err := fw.DataToExcel(filename, 1, data); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := charmap.Windows1251.NewEncoder().String("Наименование;Сумма;Примечание\r\n" +
+		`"ООО ""Рога и копыта""";1234,5;"первая; вторая"` + "\r\n" +
+		"Иванов;7;\"строка 1\nстрока 2\"\r\n")
+	if !bytes.Equal(b, []byte(want)) {
+		t.Errorf("data.csv:\n%q\nожидалось:\n%q", b, want)
+	}
+
+	fr := NewFieldsExcel("", fields, nopLogger{})
+	fr.SetCSVOptions(opts)
+	got, err := fr.ExcelToData(filename, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(data) {
+		t.Fatalf("прочитано записей %d, ожидалось %d: %v", len(got), len(data), got)
+	}
+	for i, rec := range data {
+		for k, v := range rec {
+			if got[i][k] != v {
+				t.Errorf("запись %d: %v = %q, ожидалось %q", i+1, k, got[i][k], v)
+			}
+		}
+	}
+}
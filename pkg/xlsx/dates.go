@@ -0,0 +1,183 @@
+package xlsx
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// начала отсчёта дат Excel
+var (
+	excelEpoch1900 = time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC) // для чисел от 61 (после 28.02.1900)
+	excelEpoch1904 = time.Date(1904, time.January, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// maxExcelSerial серийный номер 31.12.9999 - последняя дата Excel
+const maxExcelSerial = 2958465
+
+// locations загруженные часовые пояса полей
+var locations sync.Map
+
+// location часовой пояс по имени IANA (Europe/Moscow), пусто - UTC
+func location(name string) (*time.Location, error) {
+	if name == "" || strings.EqualFold(name, "UTC") {
+		return time.UTC, nil
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// excelSerialToTime дата по серийному номеру Excel в часовом поясе loc.
+// Учитывается система дат 1904 и ошибка Excel 1900 года: Excel считает 1900 год високосным,
+// поэтому номера до 60 отсчитываются от 31.12.1899, а номера от 61 - от 30.12.1899.
+// Номер 60 (несуществующее 29.02.1900) - ошибка. Время округляется до миллисекунд.
+func excelSerialToTime(serial float64, date1904 bool, loc *time.Location) (time.Time, error) {
+	if math.IsNaN(serial) || serial < 0 || serial >= maxExcelSerial+1 {
+		return time.Time{}, fmt.Errorf("число %v вне диапазона дат Excel", serial)
+	}
+	days := math.Floor(serial)
+	ms := math.Round((serial - days) * 86400000)
+	if ms >= 86400000 { // округление до следующих суток
+		days++
+		ms -= 86400000
+	}
+	epoch := excelEpoch1900
+	switch {
+	case date1904:
+		epoch = excelEpoch1904
+	case days == 60:
+		return time.Time{}, fmt.Errorf("число %v - дата 29.02.1900, которой не существует", serial)
+	case days < 60:
+		epoch = epoch.AddDate(0, 0, 1)
+	}
+	y, m, d := epoch.AddDate(0, 0, int(days)).Date()
+	return time.Date(y, m, d, 0, 0, 0, int(ms)*int(time.Millisecond), loc), nil
+}
+
+// ExcelTime дата по серийному номеру Excel с учётом системы дат читаемого файла (1900 или 1904)
+// и часового пояса поля (time_zone). Для использования в Converter.Parse своих типов.
+func (v FieldExcel) ExcelTime(serial float64) (time.Time, error) {
+	loc, err := location(v.TimeZone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return excelSerialToTime(serial, v.date1904, loc)
+}
+
+// layouts форматы разбора текста поля: parse и parse_formats (форматы Excel переводятся в формат Go)
+func (v FieldExcel) layouts() []string {
+	res := make([]string, 0, len(v.ParseFormats)+1)
+	if v.ParseFormat != "" {
+		res = append(res, v.ParseFormat)
+	}
+	for _, f := range v.ParseFormats {
+		res = append(res, toLayout(f))
+	}
+	return res
+}
+
+// toLayout формат разбора: формат Go как есть, формат Excel (dd.mm.yyyy) переводим в формат Go
+func toLayout(format string) string {
+	if checkLayout(format) == nil {
+		return format
+	}
+	if layout, err := excelLayout(format); err == nil {
+		return layout
+	}
+	return format
+}
+
+// ruNames замена русских названий месяцев и дней недели английскими для разбора форматов mmm, mmmm, ddd, dddd
+var ruNames = func() *strings.Replacer {
+	var pairs [][2]string
+	for i := 0; i < 12; i++ {
+		pairs = append(pairs, [2]string{LocaleRU.MonthsOf[i], LocaleEN.Months[i]}, [2]string{LocaleRU.Months[i], LocaleEN.Months[i]},
+			[2]string{LocaleRU.MonthsShort[i], LocaleEN.MonthsShort[i]})
+	}
+	for i := 0; i < 7; i++ {
+		pairs = append(pairs, [2]string{LocaleRU.Days[i], LocaleEN.Days[i]}, [2]string{LocaleRU.DaysShort[i], LocaleEN.DaysShort[i]})
+	}
+	// длинные названия раньше коротких (марта раньше мар), с прописной и строчной буквы
+	sort.SliceStable(pairs, func(i, j int) bool { return len(pairs[i][0]) > len(pairs[j][0]) })
+	var args []string
+	for _, p := range pairs {
+		r := []rune(p[0])
+		title := string(unicode.ToUpper(r[0])) + string(r[1:])
+		args = append(args, strings.ToLower(p[0]), p[1], title, p[1])
+	}
+	return strings.NewReplacer(args...)
+}()
+
+// parseTime дата или время из текста по форматам разбора поля, затем RFC 3339 (JSON, ISO 8601 с часовым поясом).
+// Текст без часового пояса - время в поясе поля, с поясом - переводится в пояс поля.
+func (v FieldExcel) parseTime(str string) (time.Time, error) {
+	loc, err := location(v.TimeZone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	str = strings.TrimSpace(str)
+	layouts := v.layouts()
+	var firstErr error
+	for _, layout := range append(layouts, time.RFC3339Nano) {
+		value := str
+		if strings.Contains(layout, "Jan") || strings.Contains(layout, "Mon") { // названия месяцев и дней на русском
+			value = ruNames.Replace(str)
+		}
+		t, err := time.ParseInLocation(layout, value, loc)
+		if err == nil {
+			return v.timeValue(t.In(loc)), nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if len(layouts) > 1 {
+		return time.Time{}, fmt.Errorf("значение %q не соответствует форматам %v", str, strings.Join(layouts, ", "))
+	}
+	return time.Time{}, firstErr
+}
+
+// timeValue значение для типа поля: у типа time дата отбрасывается (01.01.0000, как при разборе "15:04:05")
+func (v FieldExcel) timeValue(t time.Time) time.Time {
+	if v.Type != "time" {
+		return t
+	}
+	return time.Date(0, time.January, 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+// parseDateCell дата или время из ячейки: текст по форматам разбора поля или серийный номер Excel
+func parseDateCell(v FieldExcel, cell string) (interface{}, error) {
+	t, err := v.parseTime(cell)
+	if err == nil {
+		return t, nil
+	}
+	serial, errFloat := strconv.ParseFloat(strings.TrimSpace(cell), 64)
+	if errFloat != nil {
+		return nil, err
+	}
+	if v.Type == "time" { // время - дробная часть суток
+		serial -= math.Floor(serial)
+	}
+	if t, err = v.ExcelTime(serial); err != nil {
+		return nil, err
+	}
+	return v.timeValue(t), nil
+}
+
+// timeOfDay время суток как длительность от полуночи
+func timeOfDay(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+}
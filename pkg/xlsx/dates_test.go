@@ -0,0 +1,87 @@
+package xlsx
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExcelSerialToTime(t *testing.T) {
+	tests := []struct {
+		serial   float64
+		date1904 bool
+		want     time.Time
+		err      bool
+	}{
+		{serial: 1, want: time.Date(1900, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{serial: 59, want: time.Date(1900, time.February, 28, 0, 0, 0, 0, time.UTC)},
+		{serial: 60, err: true}, // 29.02.1900
+		{serial: 61, want: time.Date(1900, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		{serial: 45000, want: time.Date(2023, time.March, 15, 0, 0, 0, 0, time.UTC)},
+		{serial: 45000.5, want: time.Date(2023, time.March, 15, 12, 0, 0, 0, time.UTC)},
+		{serial: 45000.9999999999, want: time.Date(2023, time.March, 16, 0, 0, 0, 0, time.UTC)},
+		{serial: 0, date1904: true, want: time.Date(1904, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{serial: 45000, date1904: true, want: time.Date(2027, time.March, 16, 0, 0, 0, 0, time.UTC)},
+		{serial: 60, date1904: true, want: time.Date(1904, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		{serial: -1, err: true},
+		{serial: maxExcelSerial + 1, err: true},
+	}
+	for _, tt := range tests {
+		got, err := excelSerialToTime(tt.serial, tt.date1904, time.UTC)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%v (1904: %v): %v, ожидалась ошибка", tt.serial, tt.date1904, got)
+			}
+			continue
+		}
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("%v (1904: %v) = %v (%v), ожидалось %v", tt.serial, tt.date1904, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseDateCell(t *testing.T) {
+	moscow, err := location("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	field := FieldExcel{
+		Name:         "paid",
+		Type:         "datetime",
+		ParseFormat:  "02.01.2006 15:04",
+		ParseFormats: []string{"yyyy-mm-dd", "d mmmm yyyy"},
+		TimeZone:     "Europe/Moscow",
+	}
+	tests := []struct {
+		cell string
+		want time.Time
+	}{
+		{"15.03.2023 10:30", time.Date(2023, time.March, 15, 10, 30, 0, 0, moscow)},
+		{"2023-03-15", time.Date(2023, time.March, 15, 0, 0, 0, 0, moscow)},
+		{"15 марта 2023", time.Date(2023, time.March, 15, 0, 0, 0, 0, moscow)},
+		{"2023-03-15T07:30:00Z", time.Date(2023, time.March, 15, 10, 30, 0, 0, moscow)},
+		{"45000.4375", time.Date(2023, time.March, 15, 10, 30, 0, 0, moscow)},
+	}
+	for _, tt := range tests {
+		got, err := parseDateCell(field, tt.cell)
+		if err != nil {
+			t.Errorf("%q: %v", tt.cell, err)
+			continue
+		}
+		if tm, ok := got.(time.Time); !ok || !tm.Equal(tt.want) || tm.Location() != moscow {
+			t.Errorf("%q = %v, ожидалось %v", tt.cell, got, tt.want)
+		}
+	}
+
+	if _, err := parseDateCell(field, "32.13.2023"); err == nil || !strings.Contains(err.Error(), "yyyy-mm-dd") && !strings.Contains(err.Error(), "2006-01-02") {
+		t.Errorf("32.13.2023: ошибка %v, ожидался список форматов", err)
+	}
+
+	clock := FieldExcel{Name: "at", Type: "time", ParseFormat: "15:04:05"}
+	for _, cell := range []string{"18:00:00", "0.75", "45000.75"} {
+		got, err := parseDateCell(clock, cell)
+		if want := time.Date(0, time.January, 1, 18, 0, 0, 0, time.UTC); err != nil || got != want {
+			t.Errorf("время %q = %v (%v), ожидалось %v", cell, got, err, want)
+		}
+	}
+}
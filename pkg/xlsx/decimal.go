@@ -0,0 +1,189 @@
+package xlsx
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// maxDecimalScale максимальное кол-во знаков после запятой
+const maxDecimalScale = 18
+
+// excelDigits точность чисел Excel (значащих цифр)
+const excelDigits = 15
+
+// Decimal точное десятичное число (суммы платежей без ошибок округления float64).
+// Хранится как целое число без запятой и кол-во знаков после запятой; значение можно сравнивать через ==.
+type Decimal struct {
+	coef  int64 // значение без запятой
+	scale int   // знаков после запятой
+}
+
+// ParseDecimal разбор десятичного числа: "1234.56", "-0,5", "1.5E+3".
+// Лишние нули после запятой отбрасываются.
+func ParseDecimal(s string) (Decimal, error) {
+	str := strings.TrimSpace(s)
+	if str == "" {
+		return Decimal{}, fmt.Errorf("пустое значение")
+	}
+	exp := 0
+	if i := strings.IndexAny(str, "eE"); i >= 0 {
+		e, err := strconv.Atoi(str[i+1:])
+		if err != nil {
+			return Decimal{}, fmt.Errorf("значение %q не число", s)
+		}
+		exp, str = e, str[:i]
+	}
+	neg := strings.HasPrefix(str, "-")
+	str = strings.TrimLeft(str, "+-")
+	intPart, frac, _ := strings.Cut(strings.Replace(str, ",", ".", 1), ".")
+	digits := strings.TrimLeft(intPart+frac, "0")
+	if intPart+frac == "" || strings.Trim(intPart+frac, "0123456789") != "" {
+		return Decimal{}, fmt.Errorf("значение %q не число", s)
+	}
+	scale := len(frac) - exp
+	for scale < 0 { // 1.5E+3 -> 1500
+		digits += "0"
+		scale++
+	}
+	if digits == "" {
+		return Decimal{}, nil
+	}
+	if scale > maxDecimalScale {
+		cut := scale - maxDecimalScale
+		if cut >= len(digits) {
+			return Decimal{}, nil
+		}
+		digits, scale = digits[:len(digits)-cut], maxDecimalScale
+	}
+	coef, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("значение %q вне диапазона decimal", s)
+	}
+	if neg {
+		coef = -coef
+	}
+	return Decimal{coef: coef, scale: scale}.normalize(), nil
+}
+
+// DecimalFromFloat десятичное число из float64 с точностью Excel (15 значащих цифр):
+// 1234.5599999999999 из файла становится 1234.56
+func DecimalFromFloat(f float64) (Decimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Decimal{}, fmt.Errorf("значение %v не число", f)
+	}
+	return ParseDecimal(strconv.FormatFloat(f, 'e', excelDigits-1, 64))
+}
+
+// roundExcel округляем до точности Excel (15 значащих цифр): числа из ячеек Excel хранятся как double
+// и могут содержать "хвост" вида 1234.5599999999999
+func roundExcel(d Decimal) Decimal {
+	if d.coef > -1e15 && d.coef < 1e15 {
+		return d
+	}
+	res, err := DecimalFromFloat(d.Float64())
+	if err != nil {
+		return d
+	}
+	return res
+}
+
+// Add точная сумма чисел, ошибка - при выходе за диапазон decimal
+func (d Decimal) Add(other Decimal) (Decimal, error) {
+	a, ok := d.rescale(other.scale)
+	if !ok {
+		return Decimal{}, fmt.Errorf("сумма %v + %v вне диапазона decimal", d, other)
+	}
+	b, ok := other.rescale(a.scale)
+	if !ok {
+		return Decimal{}, fmt.Errorf("сумма %v + %v вне диапазона decimal", d, other)
+	}
+	sum := a.coef + b.coef
+	if (sum > a.coef) != (b.coef > 0) {
+		return Decimal{}, fmt.Errorf("сумма %v + %v вне диапазона decimal", d, other)
+	}
+	return Decimal{coef: sum, scale: a.scale}.normalize(), nil
+}
+
+// rescale число с не меньше чем scale знаков после запятой, false - при переполнении
+func (d Decimal) rescale(scale int) (Decimal, bool) {
+	for d.scale < scale {
+		if d.coef > math.MaxInt64/10 || d.coef < math.MinInt64/10 {
+			return d, false
+		}
+		d.coef *= 10
+		d.scale++
+	}
+	return d, true
+}
+
+// normalize убираем нули в конце дробной части
+func (d Decimal) normalize() Decimal {
+	for d.scale > 0 && d.coef%10 == 0 {
+		d.coef /= 10
+		d.scale--
+	}
+	return d
+}
+
+// String запись числа с точкой без экспоненты
+func (d Decimal) String() string {
+	str := strconv.FormatInt(d.coef, 10)
+	if d.scale == 0 {
+		return str
+	}
+	sign := ""
+	if d.coef < 0 {
+		sign, str = "-", str[1:]
+	}
+	if len(str) <= d.scale {
+		str = strings.Repeat("0", d.scale-len(str)+1) + str
+	}
+	return sign + str[:len(str)-d.scale] + "." + str[len(str)-d.scale:]
+}
+
+// Float64 значение в виде float64 (для вывода в Excel и расчётов)
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// IsZero значение равно нулю
+func (d Decimal) IsZero() bool {
+	return d.coef == 0
+}
+
+// MarshalText запись числа текстом (используется в JSON-строках, полях структур)
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText разбор числа из текста
+func (d *Decimal) UnmarshalText(text []byte) error {
+	res, err := ParseDecimal(string(text))
+	if err != nil {
+		return err
+	}
+	*d = res
+	return nil
+}
+
+// MarshalJSON число JSON без потери точности
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON принимаем как число, так и строку
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	return d.UnmarshalText([]byte(strings.Trim(string(b), `"`)))
+}
+
+// Value значение для параметра запроса к базе данных (текстом, без потери точности)
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
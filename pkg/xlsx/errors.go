@@ -0,0 +1,162 @@
+package xlsx
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// CellError ошибка разбора или преобразования значения ячейки
+type CellError struct {
+	Sheet  string // имя листа
+	Row    int    // номер строки в файле (при записи - номер записи в данных)
+	Column string // буква колонки
+	Field  string // имя поля
+	Value  string // исходное значение
+	Type   string // ожидаемый тип
+	Reason string // причина ошибки
+}
+
+func (e CellError) Error() string {
+	return fmt.Sprintf("лист %q, строка %d, колонка %s (%s): значение %q (тип %s): %s",
+		e.Sheet, e.Row, e.Column, e.Field, e.Value, e.Type, e.Reason)
+}
+
+// CellErrors список ошибок ячеек
+type CellErrors []CellError
+
+func (e CellErrors) Error() string {
+	switch len(e) {
+	case 0:
+		return "нет ошибок"
+	case 1:
+		return e[0].Error()
+	}
+	return fmt.Sprintf("%v (и ещё ошибок: %d)", e[0].Error(), len(e)-1)
+}
+
+// newCellError заполняем ошибку ячейки
+func (s FieldsExcel) newCellError(row, col int, v FieldExcel, val interface{}, reason error) CellError {
+	letter, _ := excelize.ColumnNumberToName(col)
+	value := ""
+	if val != nil {
+		value = fmt.Sprint(val)
+	}
+	return CellError{
+		Sheet:  s.sheetName,
+		Row:    row,
+		Column: letter,
+		Field:  v.Name,
+		Value:  value,
+		Type:   v.Type,
+		Reason: reasonText(reason),
+	}
+}
+
+// reasonText понятное описание причины ошибки
+func reasonText(err error) string {
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		if errors.Is(numErr.Err, strconv.ErrRange) {
+			return "число вне допустимого диапазона"
+		}
+		return "значение не является числом"
+	}
+	var parseErr *time.ParseError
+	if errors.As(err, &parseErr) {
+		return fmt.Sprintf("дата не соответствует формату %v", parseErr.Layout)
+	}
+	return err.Error()
+}
+
+// errorsHeader заголовок отчёта об ошибках
+var errorsHeader = []string{"Лист", "Строка", "Колонка", "Поле", "Значение", "Тип", "Ошибка"}
+
+func (e CellError) values() []string {
+	return []string{e.Sheet, strconv.Itoa(e.Row), e.Column, e.Field, e.Value, e.Type, e.Reason}
+}
+
+// SaveCSV сохраняем отчёт об ошибках в CSV-файл (UTF-8 с BOM, разделитель ";")
+func (e CellErrors) SaveCSV(filename string, opts SaveOptions) error {
+	return writeFileAtomic(filename, opts, func(file io.Writer) error {
+		if _, err := io.WriteString(file, "\ufeff"); err != nil { // BOM, чтобы Excel понял кодировку
+			return err
+		}
+		w := csv.NewWriter(file)
+		w.Comma = ';'
+		if err := w.Write(errorsHeader); err != nil {
+			return err
+		}
+		for _, ce := range e {
+			if err := w.Write(ce.values()); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	})
+}
+
+// WriteSheet записываем отчёт об ошибках на лист sheetName (лист пересоздаётся)
+func (e CellErrors) WriteSheet(f *excelize.File, sheetName string) error {
+	if index, _ := f.GetSheetIndex(sheetName); index != -1 {
+		f.DeleteSheet(sheetName)
+	}
+	if _, err := f.NewSheet(sheetName); err != nil {
+		return fmt.Errorf("NewSheet %v", err)
+	}
+	header := make([]interface{}, len(errorsHeader))
+	for i, h := range errorsHeader {
+		header[i] = h
+	}
+	if err := f.SetSheetRow(sheetName, "A1", &header); err != nil {
+		return fmt.Errorf("SetSheetRow %v", err)
+	}
+	for i, ce := range e {
+		row := []interface{}{ce.Sheet, ce.Row, ce.Column, ce.Field, ce.Value, ce.Type, ce.Reason}
+		addr, _ := excelize.CoordinatesToCellName(1, i+2)
+		if err := f.SetSheetRow(sheetName, addr, &row); err != nil {
+			return fmt.Errorf("SetSheetRow %v", err)
+		}
+	}
+	for i, width := range []float64{15, 8, 8, 15, 25, 10, 60} {
+		col, _ := excelize.ColumnNumberToName(i + 1)
+		if err := f.SetColWidth(sheetName, col, col, width); err != nil {
+			return fmt.Errorf("SetColWidth %v", err)
+		}
+	}
+	return nil
+}
+
+// SaveSheet сохраняем отчёт об ошибках на лист sheetName Excel-файла (файл создаётся при отсутствии)
+func (e CellErrors) SaveSheet(filename, sheetName string, opts SaveOptions) error {
+	wb, err := OpenOrNewWorkbook(filename)
+	if err != nil {
+		return err
+	}
+	defer wb.Close()
+	wb.SetSaveOptions(opts)
+
+	if err = e.WriteSheet(wb.f, sheetName); err != nil {
+		return err
+	}
+	return wb.Save()
+}
+
+// Save сохраняем отчёт об ошибках: в CSV для файлов *.csv, иначе на лист sheetName Excel-файла
+func (e CellErrors) Save(filename, sheetName string, opts SaveOptions) error {
+	if strings.EqualFold(filepath.Ext(filename), ".csv") {
+		return e.SaveCSV(filename, opts)
+	}
+	if sheetName == "" {
+		sheetName = "Ошибки"
+	}
+	return e.SaveSheet(filename, sheetName, opts)
+}
@@ -0,0 +1,87 @@
+package xlsx
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestCollectCellErrors(t *testing.T) {
+	filename := writeBook(t, [][]interface{}{
+		{"Ф.И.О.", "Сумма", "Дата"},
+		{"Иванов", 10, "01.03.2023"},
+		{"Петров", "много", "02.03.2023"},
+		{"Сидоров", 30, "31.02.2023"},
+		{"Козлов", 40, "04.03.2023"},
+	})
+	fe := newTestFields(map[int]FieldExcel{
+		1: {Name: "fio"},
+		2: {Name: "sum", Type: "int64"},
+		3: {Name: "paid", Type: "date", ParseFormat: "02.01.2006"},
+	})
+
+	data, errs, err := fe.ExcelToDataValidate(filename, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 2 || data[0]["fio"] != "Иванов" || data[1]["fio"] != "Козлов" {
+		t.Errorf("записи без ошибок: %v", data)
+	}
+	want := []CellError{
+		{Sheet: "Лист1", Row: 3, Column: "B", Field: "sum", Value: "много", Type: "int64", Reason: "значение не является числом"},
+		{Sheet: "Лист1", Row: 4, Column: "C", Field: "paid", Value: "31.02.2023", Type: "date"},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("ошибок %d, ожидалось %d: %v", len(errs), len(want), errs)
+	}
+	for i, w := range want {
+		got := errs[i]
+		if w.Reason == "" {
+			w.Reason = got.Reason // причина разбора даты зависит от текста ошибки time.Parse
+		}
+		if got != w {
+			t.Errorf("ошибка %d: %+v, ожидалось %+v", i+1, got, w)
+		}
+	}
+
+	dir := t.TempDir()
+	csvFile := filepath.Join(dir, "errors.csv")
+	if err = errs.Save(csvFile, "", SaveOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(csvFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(b), "\ufeff")))
+	r.Comma = ';'
+	records, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || !reflect.DeepEqual(records[0], errorsHeader) || !reflect.DeepEqual(records[1], errs[0].values()) {
+		t.Errorf("errors.csv: %q", records)
+	}
+
+	xlsxFile := filepath.Join(dir, "errors.xlsx")
+	if err = errs.Save(xlsxFile, "", SaveOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := excelize.OpenFile(xlsxFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := f.GetRows("Ошибки")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || !reflect.DeepEqual(rows[2], errs[1].values()) {
+		t.Errorf("лист Ошибки: %q", rows)
+	}
+}
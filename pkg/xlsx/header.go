@@ -0,0 +1,152 @@
+package xlsx
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// headerSearchLimit сколько непустых строк просматриваем в поисках строки заголовка
+const headerSearchLimit = 20
+
+// HeaderError ошибка сопоставления заголовков колонок файла с описанием полей
+type HeaderError struct {
+	Row        int      // номер строки заголовка (0 - строка не найдена)
+	Missing    []string // заголовки из описания полей, которых нет в файле
+	Unexpected []string // заголовки файла, не найденные в описании полей
+}
+
+func (e *HeaderError) Error() string {
+	var sb strings.Builder
+	if e.Row > 0 {
+		fmt.Fprintf(&sb, "заголовки колонок не совпадают (строка %d)", e.Row)
+	} else {
+		sb.WriteString("строка заголовка не найдена")
+	}
+	if len(e.Missing) > 0 {
+		sb.WriteString("; нет в файле: " + strings.Join(e.Missing, ", "))
+	}
+	if len(e.Unexpected) > 0 {
+		sb.WriteString("; лишние в файле: " + strings.Join(e.Unexpected, ", "))
+	}
+	return sb.String()
+}
+
+// UseHeaders включает сопоставление полей с колонками по заголовкам.
+// headerRow - номер строки заголовка в файле, 0 - искать строку автоматически.
+// Поля без заголовка берутся по номеру колонки (ключу в fields).
+func (s *FieldsExcel) UseHeaders(headerRow int) {
+	s.matchHeaders = true
+	s.headerRow = headerRow
+}
+
+// emptyHeaderError ошибка: в заданной строке заголовка (header_row) нет значений
+func emptyHeaderError(rowNum int) error {
+	return fmt.Errorf("строка заголовка %d пуста", rowNum)
+}
+
+// blankRow в строке нет непустых значений
+func blankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeHeader приводим заголовок к виду для сравнения (регистр, пробелы)
+func normalizeHeader(h string) string {
+	h = strings.ReplaceAll(strings.ToLower(h), "ё", "е")
+	return strings.Join(strings.Fields(h), " ")
+}
+
+// headerNames все допустимые написания заголовка поля
+func (v FieldExcel) headerNames() []string {
+	names := make([]string, 0, len(v.Aliases)+1)
+	if v.Header != "" {
+		names = append(names, normalizeHeader(v.Header))
+	}
+	for _, a := range v.Aliases {
+		if a = normalizeHeader(a); a != "" {
+			names = append(names, a)
+		}
+	}
+	return names
+}
+
+// sortedKeys номера колонок описания полей по возрастанию
+func (s FieldsExcel) sortedKeys() []int {
+	keys := make([]int, 0, len(s.fields))
+	for key := range s.fields {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// defaultColumns соответствие полей колонкам по номерам (ключам fields)
+func (s FieldsExcel) defaultColumns() map[int]int {
+	columns := make(map[int]int, len(s.fields))
+	for key := range s.fields {
+		columns[key] = key
+	}
+	return columns
+}
+
+// matchHeaderRow сопоставляет строку заголовка с описанием полей.
+// Возвращает соответствие ключ поля -> номер колонки в файле и ошибку с отчётом о расхождениях.
+func (s FieldsExcel) matchHeaderRow(row []string, rowNum int) (map[int]int, *HeaderError) {
+	columns := make(map[int]int, len(s.fields))
+	used := make(map[int]bool, len(row))
+	herr := &HeaderError{Row: rowNum}
+
+	for _, key := range s.sortedKeys() {
+		v := s.fields[key]
+		names := v.headerNames()
+		if len(names) == 0 { // без заголовка - берём по номеру колонки
+			columns[key] = key
+			used[key] = true
+			continue
+		}
+		found := false
+		for i, cell := range row {
+			if used[i+1] {
+				continue
+			}
+			cell = normalizeHeader(cell)
+			for _, name := range names {
+				if cell == name {
+					found = true
+					break
+				}
+			}
+			if found {
+				columns[key] = i + 1
+				used[i+1] = true
+				break
+			}
+		}
+		if !found {
+			herr.Missing = append(herr.Missing, fmt.Sprintf("%q", v.Header))
+		}
+	}
+
+	for i, cell := range row {
+		if used[i+1] || strings.TrimSpace(cell) == "" {
+			continue
+		}
+		letter, _ := excelize.ColumnNumberToName(i + 1)
+		herr.Unexpected = append(herr.Unexpected, fmt.Sprintf("%q (%s)", cell, letter))
+	}
+
+	if len(herr.Missing) > 0 {
+		return columns, herr
+	}
+	if len(herr.Unexpected) > 0 {
+		s.log.Debugf("Колонки не описаны в настройках: %v", strings.Join(herr.Unexpected, ", "))
+	}
+	return columns, nil
+}
@@ -0,0 +1,418 @@
+package xlsx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// InspectOptions настройки просмотра книги
+type InspectOptions struct {
+	Sheets  []string // листы (по умолчанию все)
+	Preview int      // сколько первых строк показать (по умолчанию 10)
+	Scan    int      // сколько строк данных просматривать для определения типов (по умолчанию 1000)
+}
+
+// SheetInfo описание листа книги
+type SheetInfo struct {
+	Name        string
+	Dimension   string       // диапазон заполненной части листа, например A1:E1408
+	Rows        int          // номер последней заполненной строки
+	Columns     int          // номер последней заполненной колонки
+	HeaderRow   int          // вероятная строка заголовка (0 - не найдена)
+	Preview     [][]string   // первые непустые строки листа (значения как в Excel)
+	PreviewRows []int        // номера строк Preview на листе
+	Fields      []ColumnInfo // колонки листа
+}
+
+// ColumnInfo описание колонки листа
+type ColumnInfo struct {
+	Index  int    // номер колонки
+	Letter string // буква колонки
+	Header string // заголовок (из строки заголовка)
+	Type   string // вероятный тип: int64, float64, date, пусто - текст
+	Parse  string // формат разбора для дат текстом
+	Filled int    // кол-во непустых значений в просмотренных строках данных
+	Sample string // пример значения
+}
+
+// textDateLayouts форматы дат текстом, которые распознаются при просмотре
+var textDateLayouts = []string{"02.01.2006", "2006-01-02", "02/01/2006", "02.01.2006 15:04:05", "2006-01-02 15:04:05"}
+
+// Inspect описание листов Excel-файла
+func Inspect(filename string, opts InspectOptions) ([]SheetInfo, error) {
+	wb, err := OpenWorkbook(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer wb.Close()
+	return wb.Inspect(opts)
+}
+
+// Inspect описание листов книги: размеры, первые строки, строка заголовка и типы колонок
+func (wb *Workbook) Inspect(opts InspectOptions) ([]SheetInfo, error) {
+	if opts.Preview <= 0 {
+		opts.Preview = 10
+	}
+	if opts.Scan <= 0 {
+		opts.Scan = 1000
+	}
+	sheets := opts.Sheets
+	if len(sheets) == 0 {
+		sheets = wb.f.GetSheetList()
+	}
+	result := make([]SheetInfo, 0, len(sheets))
+	for _, sheet := range sheets {
+		if idx, _ := wb.f.GetSheetIndex(sheet); idx < 0 {
+			return nil, fmt.Errorf("лист %v не найден", sheet)
+		}
+		info, err := inspectSheet(wb.f, sheet, opts)
+		if err != nil {
+			return nil, fmt.Errorf("лист %v: %w", sheet, err)
+		}
+		result = append(result, info)
+	}
+	return result, nil
+}
+
+// inspectCell значение ячейки для определения типа
+type inspectCell struct {
+	raw  string
+	date bool // число с форматом даты
+}
+
+// inspectSheet описание одного листа
+func inspectSheet(f *excelize.File, sheet string, opts InspectOptions) (SheetInfo, error) {
+	info := SheetInfo{Name: sheet}
+	rows, err := f.Rows(sheet)
+	if err != nil {
+		return info, fmt.Errorf("f.Rows %v", err)
+	}
+	defer rows.Close()
+	attrs, err := openSheetCells(f, sheet) // стили ячеек читаются потоком, без загрузки листа в память
+	if err != nil {
+		return info, err
+	}
+	defer attrs.Close()
+
+	var (
+		head     [][]inspectCell // строки до начала данных и просматриваемые строки
+		rowNums  []int
+		formats  = newCellFormats(f)
+		firstRow int // первая заполненная строка
+		firstCol int // первая заполненная колонка
+	)
+	for r := 1; rows.Next(); r++ {
+		raw, err := rows.Columns(excelize.Options{RawCellValue: true})
+		if err != nil {
+			return info, fmt.Errorf("rows.Columns %v", err)
+		}
+		for len(raw) > 0 && strings.TrimSpace(raw[len(raw)-1]) == "" {
+			raw = raw[:len(raw)-1]
+		}
+		if len(raw) == 0 {
+			continue
+		}
+		if firstRow == 0 {
+			firstRow = r
+		}
+		for c := range raw {
+			if strings.TrimSpace(raw[c]) != "" {
+				if firstCol == 0 || c+1 < firstCol {
+					firstCol = c + 1
+				}
+				break
+			}
+		}
+		info.Rows = r
+		if len(raw) > info.Columns {
+			info.Columns = len(raw)
+		}
+		if r <= opts.Preview {
+			formatted := make([]string, len(raw))
+			for c := range raw {
+				attr, err := attrs.cell(c+1, r)
+				if err != nil {
+					return info, err
+				}
+				formatted[c] = formats.display(attr, raw[c])
+			}
+			info.Preview = append(info.Preview, formatted)
+			info.PreviewRows = append(info.PreviewRows, r)
+		}
+		if len(head) < headerSearchLimit+opts.Scan {
+			cells := make([]inspectCell, len(raw))
+			for c, v := range raw {
+				cells[c] = inspectCell{raw: v}
+				if _, err := strconv.ParseFloat(v, 64); err == nil && v != "" {
+					attr, err := attrs.cell(c+1, r)
+					if err != nil {
+						return info, err
+					}
+					cells[c].date = formats.numFormat(attr.style).IsDate()
+				}
+			}
+			head = append(head, cells)
+			rowNums = append(rowNums, r)
+		}
+	}
+	if info.Rows == 0 {
+		return info, nil
+	}
+	first, _ := excelize.CoordinatesToCellName(firstCol, firstRow)
+	last, _ := excelize.CoordinatesToCellName(info.Columns, info.Rows)
+	info.Dimension = first + ":" + last
+
+	// строка заголовка: первая строка из текстовых значений, за которой идут данные
+	start := 0
+	for i, cells := range head {
+		if i >= headerSearchLimit || i+1 >= len(head) {
+			break
+		}
+		if isHeaderRow(cells, info.Columns) {
+			info.HeaderRow = rowNums[i]
+			start = i + 1
+			break
+		}
+	}
+
+	info.Fields = make([]ColumnInfo, info.Columns)
+	for c := range info.Fields {
+		col := ColumnInfo{Index: c + 1}
+		col.Letter, _ = excelize.ColumnNumberToName(c + 1)
+		if info.HeaderRow > 0 && c < len(head[start-1]) {
+			col.Header = strings.TrimSpace(head[start-1][c].raw)
+		}
+		var values []inspectCell
+		for _, cells := range head[start:] {
+			if c < len(cells) && strings.TrimSpace(cells[c].raw) != "" {
+				values = append(values, cells[c])
+			}
+		}
+		col.Filled = len(values)
+		if len(values) > 0 {
+			col.Sample = strings.TrimSpace(values[0].raw)
+		}
+		col.Type, col.Parse = guessType(values)
+		info.Fields[c] = col
+	}
+	return info, nil
+}
+
+// isHeaderRow строка похожа на заголовок: не меньше половины колонок заполнено разными текстами
+func isHeaderRow(cells []inspectCell, columns int) bool {
+	filled := 0
+	seen := make(map[string]bool, len(cells))
+	for _, cell := range cells {
+		v := strings.TrimSpace(cell.raw)
+		if v == "" {
+			continue
+		}
+		if _, err := strconv.ParseFloat(v, 64); err == nil {
+			return false
+		}
+		if _, ok := parseTextDate(v); ok || seen[normalizeHeader(v)] {
+			return false
+		}
+		seen[normalizeHeader(v)] = true
+		filled++
+	}
+	return filled > 0 && filled*2 >= columns
+}
+
+// guessType тип колонки по значениям: целые - int64, числа - float64, даты - date.
+// Тип выбирается, если ему соответствуют не меньше 95% значений (остальное - ошибки в данных).
+func guessType(values []inspectCell) (string, string) {
+	if len(values) == 0 {
+		return "", ""
+	}
+	ints, floats, dates := 0, 0, 0
+	layout := ""
+	for _, cell := range values {
+		v := strings.TrimSpace(cell.raw)
+		if num, err := strconv.ParseFloat(v, 64); err == nil {
+			switch {
+			case cell.date:
+				dates++
+			case num == float64(int64(num)) && !strings.ContainsAny(v, ".eE"):
+				ints++
+			default:
+				floats++
+			}
+			continue
+		}
+		if l, ok := parseTextDate(v); ok && (layout == "" || layout == l) {
+			layout = l
+			dates++
+		}
+	}
+	need := len(values) - len(values)/20
+	switch {
+	case ints >= need:
+		return "int64", ""
+	case ints+floats >= need:
+		return "float64", ""
+	case dates >= need:
+		return "date", layout
+	}
+	return "", ""
+}
+
+// parseTextDate формат даты, записанной текстом
+func parseTextDate(v string) (string, bool) {
+	for _, layout := range textDateLayouts {
+		if _, err := time.Parse(layout, v); err == nil {
+			return layout, true
+		}
+	}
+	return "", false
+}
+
+// FieldsConfig описание полей в виде блока read_file_settings для конфига
+func (si SheetInfo) FieldsConfig() string {
+	type field struct {
+		Name   string `json:"name"`
+		Header string `json:"header,omitempty"`
+		Type   string `json:"type,omitempty"`
+		Format string `json:"format,omitempty"`
+		Parse  string `json:"parse,omitempty"`
+	}
+	startRow := si.HeaderRow + 1
+	matchHeaders := si.HeaderRow > 0
+
+	var sb strings.Builder
+	sb.WriteString("\"read_file_settings\": {\n")
+	fmt.Fprintf(&sb, "\t\"sheet_name\": %s,\n", jsonText(si.Name))
+	fmt.Fprintf(&sb, "\t\"start_row\": %d,\n", startRow)
+	if matchHeaders {
+		sb.WriteString("\t\"match_headers\": true,\n")
+	}
+	sb.WriteString("\t\"fields\": {")
+	names := make(map[string]bool, len(si.Fields))
+	first := true
+	for _, col := range si.Fields {
+		if col.Filled == 0 && col.Header == "" {
+			continue
+		}
+		fd := field{Name: fieldName(col, names), Header: col.Header, Type: col.Type, Parse: col.Parse}
+		switch col.Type {
+		case "float64":
+			fd.Format = "#,##0.00"
+		case "date":
+			fd.Format = "dd.mm.yyyy"
+			if fd.Parse == "02.01.2006" {
+				fd.Parse = "" // формат по умолчанию
+			}
+		}
+		if !first {
+			sb.WriteString(",")
+		}
+		first = false
+		fmt.Fprintf(&sb, "\n\t\t\"%d\": %s", col.Index, jsonText(fd))
+	}
+	sb.WriteString("\n\t}\n}")
+	return sb.String()
+}
+
+// WriteText выводим описание листа в текстовом виде
+func (si SheetInfo) WriteText(w io.Writer) error {
+	if si.Rows == 0 {
+		_, err := fmt.Fprintf(w, "Лист %q: пустой\n\n", si.Name)
+		return err
+	}
+	fmt.Fprintf(w, "Лист %q: %s, строк %d, колонок %d\n", si.Name, si.Dimension, si.Rows, si.Columns)
+	if si.HeaderRow > 0 {
+		fmt.Fprintf(w, "Строка заголовка: %d\n", si.HeaderRow)
+	} else {
+		fmt.Fprintln(w, "Строка заголовка не найдена")
+	}
+
+	fmt.Fprintln(w, "\nПервые строки:")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprint(tw, "№")
+	for _, col := range si.Fields {
+		fmt.Fprintf(tw, "\t%s (%d)", col.Letter, col.Index)
+	}
+	fmt.Fprintln(tw)
+	for i, row := range si.Preview {
+		fmt.Fprint(tw, si.PreviewRows[i])
+		for _, v := range row {
+			fmt.Fprintf(tw, "\t%s", shorten(v, 30))
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w, "\nКолонки:")
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "Колонка\tЗаголовок\tТип\tЗаполнено\tПример")
+	for _, col := range si.Fields {
+		typ := col.Type
+		if typ == "" {
+			typ = "text"
+		}
+		fmt.Fprintf(tw, "%s (%d)\t%s\t%s\t%d\t%s\n", col.Letter, col.Index, shorten(col.Header, 30), typ, col.Filled, shorten(col.Sample, 30))
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\nОписание полей для конфига:\n%s\n\n", si.FieldsConfig())
+	return nil
+}
+
+// fieldName имя поля по заголовку (транслитерация) или по букве колонки, names - занятые имена
+func fieldName(col ColumnInfo, names map[string]bool) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(col.Header) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			sb.WriteRune(r)
+		case translit[r] != "" || r == 'ъ' || r == 'ь':
+			sb.WriteString(translit[r])
+		default:
+			if s := sb.String(); s != "" && !strings.HasSuffix(s, "_") {
+				sb.WriteByte('_')
+			}
+		}
+	}
+	name := strings.Trim(sb.String(), "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "col_" + strings.ToLower(col.Letter)
+	}
+	for base, i := name, 2; names[name]; i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+	names[name] = true
+	return name
+}
+
+var translit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh", 'з': "z", 'и': "i",
+	'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+	'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ы': "y", 'э': "e",
+	'ю': "yu", 'я': "ya",
+}
+
+// jsonText значение в JSON без экранирования HTML-символов
+func jsonText(v interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+	return strings.TrimSpace(buf.String())
+}
+
+// shorten обрезаем длинное значение для вывода
+func shorten(v string, max int) string {
+	v = strings.Join(strings.Fields(v), " ")
+	if r := []rune(v); len(r) > max {
+		return string(r[:max-1]) + "…"
+	}
+	return v
+}
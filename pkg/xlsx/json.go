@@ -0,0 +1,229 @@
+package xlsx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// JSONOptions настройки записи JSON/NDJSON
+type JSONOptions struct {
+	DateLayout string `json:"date_layout,omitempty"` // формат дат: пусто - формат разбора поля (parse), "rfc3339" - RFC 3339
+}
+
+// SetJSONOptions задаёт настройки записи JSON/NDJSON
+func (s *FieldsExcel) SetJSONOptions(opts JSONOptions) {
+	s.json = opts
+}
+
+// isJSON файл в формате JSON или NDJSON
+func (s FieldsExcel) isJSON(filename string) bool {
+	format := s.fileFormat(filename)
+	return format == FormatJSON || format == FormatNDJSON
+}
+
+// jsonSource записи JSON-файла (массив объектов или объекты подряд), объекты разбираются по одному.
+// Значения полей выдаются строкой по номеру поля, как колонки листа.
+type jsonSource struct {
+	fe     *FieldsExcel
+	file   *os.File
+	dec    *json.Decoder
+	array  bool // файл - массив объектов
+	record int
+	row    []string
+	err    error
+}
+
+// openJSON открываем JSON/NDJSON-файл для чтения
+func (s *FieldsExcel) openJSON(filename string) (*jsonSource, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	if s.sheetName == "" {
+		s.sheetName = filepath.Base(filename)
+	}
+	br := bufio.NewReader(file)
+	if ch, _, err := br.ReadRune(); err == nil && ch != '\uFEFF' { // пропускаем BOM
+		br.UnreadRune()
+	}
+	src := &jsonSource{fe: s, file: file, dec: json.NewDecoder(br)}
+	src.dec.UseNumber()
+
+	// массив объектов начинается с [
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			break
+		}
+		if b[0] == ' ' || b[0] == '\t' || b[0] == '\r' || b[0] == '\n' {
+			br.ReadByte()
+			continue
+		}
+		if b[0] == '[' {
+			src.array = true
+			if _, err = src.dec.Token(); err != nil {
+				file.Close()
+				return nil, err
+			}
+		}
+		break
+	}
+	return src, nil
+}
+
+func (src *jsonSource) Next() bool {
+	if src.array && !src.dec.More() {
+		return false
+	}
+	var obj map[string]interface{}
+	src.err = src.dec.Decode(&obj)
+	if src.err == io.EOF {
+		return false
+	}
+	src.record++
+	if src.err == nil {
+		src.row, src.err = src.fe.objectToRow(obj)
+	}
+	return true
+}
+
+func (src *jsonSource) Columns() ([]string, error) {
+	if src.err != nil {
+		return nil, fmt.Errorf("запись %d: %w", src.record, src.err)
+	}
+	return src.row, nil
+}
+
+func (src *jsonSource) Close() error {
+	return src.file.Close()
+}
+
+// objectToRow значения объекта по номерам полей (номер поля - номер колонки)
+func (s FieldsExcel) objectToRow(obj map[string]interface{}) ([]string, error) {
+	row := make([]string, s.MaxColumn())
+	for key, v := range s.fields {
+		val, ok := obj[v.Name]
+		if !ok || val == nil {
+			continue
+		}
+		switch x := val.(type) {
+		case string: // даты в RFC 3339 разбираются вместе с форматами поля
+			row[key-1] = x
+		case json.Number:
+			row[key-1] = x.String()
+		case bool:
+			row[key-1] = fmt.Sprint(x)
+		default: // вложенные объекты и массивы - текстом JSON
+			b, err := json.Marshal(x)
+			if err != nil {
+				return nil, err
+			}
+			row[key-1] = string(b)
+		}
+	}
+	return row, nil
+}
+
+// dataToJSON запись данных в JSON/NDJSON-файл, collect - собирать ошибки вместо прерывания записи
+func (s *FieldsExcel) dataToJSON(filename string, next NextRecord, collect bool) (CellErrors, error) {
+	if s.sheetName == "" {
+		s.sheetName = filepath.Base(filename)
+	}
+	ndjson := s.fileFormat(filename) == FormatNDJSON
+	keys := s.sortedKeys()
+
+	var errs CellErrors
+	err := writeFileAtomic(filename, s.save, func(file io.Writer) error {
+		w := bufio.NewWriter(file)
+		if !ndjson {
+			w.WriteString("[")
+		}
+		count := 0
+		var buf bytes.Buffer
+		for r := 0; ; r++ {
+			record, err := next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+
+			buf.Reset()
+			buf.WriteByte('{')
+			var rowErrs CellErrors
+			for i, key := range keys {
+				v := s.fields[key]
+				val, err := s.jsonValue(v, record[v.Name])
+				if err != nil {
+					rowErrs = append(rowErrs, s.newCellError(r+1, key, v, record[v.Name], err))
+					continue
+				}
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				name, _ := json.Marshal(v.Name)
+				buf.Write(name)
+				buf.WriteByte(':')
+				buf.Write(val)
+			}
+			buf.WriteByte('}')
+			if len(rowErrs) > 0 {
+				if !collect {
+					return rowErrs
+				}
+				errs = append(errs, rowErrs...) // запись с ошибками пропускаем
+				continue
+			}
+
+			switch {
+			case ndjson:
+			case count > 0:
+				w.WriteString(",\n")
+			default:
+				w.WriteString("\n")
+			}
+			w.Write(buf.Bytes())
+			if ndjson {
+				w.WriteString("\n")
+			}
+			count++
+		}
+		if !ndjson {
+			w.WriteString("\n]\n")
+		}
+		return w.Flush()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
+
+// jsonValue значение поля в JSON: числа - числами, даты - в формате поля или RFC 3339
+func (s FieldsExcel) jsonValue(v FieldExcel, val interface{}) ([]byte, error) {
+	if str, ok := val.(string); ok && !v.IsText() && strings.TrimSpace(str) == "" {
+		val = nil // пустая ячейка типизированного поля
+	}
+	cell, err := valueToCell(v, val)
+	if err != nil {
+		return nil, err
+	}
+	switch x := cell.(type) {
+	case time.Duration: // длительность - текстом ч:мм:сс
+		return json.Marshal(FormatDuration(x))
+	case time.Time:
+		if v.Type != "time" && strings.EqualFold(s.json.DateLayout, "rfc3339") {
+			return json.Marshal(x.Format(time.RFC3339))
+		}
+		return json.Marshal(x.Format(v.ParseFormat))
+	}
+	return json.Marshal(cell)
+}
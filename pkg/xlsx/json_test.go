@@ -0,0 +1,90 @@
+package xlsx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	fields := map[int]FieldExcel{
+		1: {Name: "fio", Header: "Ф.И.О."},
+		2: {Name: "sum", Header: "Сумма", Type: "int64"},
+		3: {Name: "rate", Header: "Ставка", Type: "float64"},
+		4: {Name: "paid", Header: "Дата", Type: "date", ParseFormat: "02.01.2006"},
+		5: {Name: "active", Header: "Активен", Type: "bool"},
+	}
+	data := []map[string]interface{}{
+		{"fio": `Иванов "мл."`, "sum": int64(10), "rate": 0.5, "paid": time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC), "active": true},
+		{"fio": "Петров", "sum": "", "rate": 1.25, "paid": time.Date(2023, time.March, 2, 0, 0, 0, 0, time.UTC), "active": false},
+	}
+	dir := t.TempDir()
+
+	for name, want := range map[string]string{
+		"data.ndjson": `{"fio":"Иванов \"мл.\"","sum":10,"rate":0.5,"paid":"01.03.2023","active":true}` + "\n" +
+			`{"fio":"Петров","sum":null,"rate":1.25,"paid":"02.03.2023","active":false}` + "\n",
+		"data.json": "[\n" +
+			`{"fio":"Иванов \"мл.\"","sum":10,"rate":0.5,"paid":"01.03.2023","active":true},` + "\n" +
+			`{"fio":"Петров","sum":null,"rate":1.25,"paid":"02.03.2023","active":false}` + "\n]\n",
+	} {
+		filename := filepath.Join(dir, name)
+		fw := NewFieldsExcel("", fields, nopLogger{})
+		if err := fw.DataToExcel(filename, 1, data); err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("%v:\n%s\nожидалось:\n%s", name, b, want)
+		}
+
+		fr := NewFieldsExcel("", fields, nopLogger{})
+		got, err := fr.ExcelToData(filename, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(data) {
+			t.Fatalf("%v: прочитано записей %d, ожидалось %d", name, len(got), len(data))
+		}
+		for i, rec := range data {
+			for k, v := range rec {
+				if v == "" {
+					v = nil // пустое значение типизированного поля
+				}
+				if got[i][k] != v {
+					t.Errorf("%v, запись %d: %v = %v (%T), ожидалось %v (%T)", name, i+1, k, got[i][k], got[i][k], v, v)
+				}
+			}
+		}
+	}
+}
+
+func TestJSONDateLayoutRFC3339(t *testing.T) {
+	fields := map[int]FieldExcel{1: {Name: "paid", Type: "date", ParseFormat: "02.01.2006"}}
+	filename := filepath.Join(t.TempDir(), "data.ndjson")
+	paid := time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	fw := NewFieldsExcel("", fields, nopLogger{})
+	fw.SetJSONOptions(JSONOptions{DateLayout: "rfc3339"})
+	if err := fw.DataToExcel(filename, 1, []map[string]interface{}{{"paid": paid}}); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(filename); string(b) != `{"paid":"2023-03-01T00:00:00Z"}`+"\n" {
+		t.Errorf("data.ndjson: %s", b)
+	}
+
+	fr := NewFieldsExcel("", fields, nopLogger{})
+	got, err := fr.ExcelToData(filename, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("прочитано записей %d, ожидалась 1", len(got))
+	}
+	if got, ok := got[0]["paid"].(time.Time); !ok || !got.Equal(paid) {
+		t.Errorf("paid = %v, ожидалось %v", got, paid)
+	}
+}
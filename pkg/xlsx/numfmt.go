@@ -0,0 +1,1058 @@
+package xlsx
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Locale названия месяцев и дней недели, разделители числа для вывода значений по формату Excel
+type Locale struct {
+	Months      [12]string // Январь ...
+	MonthsOf    [12]string // января ... - после числа (d mmmm), пусто - как Months
+	MonthsShort [12]string // янв ...
+	Days        [7]string  // воскресенье ... (как time.Weekday)
+	DaysShort   [7]string  // Вс ...
+	AM, PM      string
+	Decimal     string // десятичный разделитель
+	Thousands   string // разделитель тысяч
+}
+
+// локали форматов: по умолчанию русская (как язык стилей книги), [$-409] в формате - английская
+var (
+	LocaleRU = Locale{
+		Months:      [12]string{"Январь", "Февраль", "Март", "Апрель", "Май", "Июнь", "Июль", "Август", "Сентябрь", "Октябрь", "Ноябрь", "Декабрь"},
+		MonthsOf:    [12]string{"января", "февраля", "марта", "апреля", "мая", "июня", "июля", "августа", "сентября", "октября", "ноября", "декабря"},
+		MonthsShort: [12]string{"янв", "фев", "мар", "апр", "май", "июн", "июл", "авг", "сен", "окт", "ноя", "дек"},
+		Days:        [7]string{"воскресенье", "понедельник", "вторник", "среда", "четверг", "пятница", "суббота"},
+		DaysShort:   [7]string{"Вс", "Пн", "Вт", "Ср", "Чт", "Пт", "Сб"},
+		AM:          "AM", PM: "PM",
+		Decimal: ",", Thousands: "\u00a0",
+	}
+	LocaleEN = Locale{
+		Months:      [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		MonthsShort: [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		Days:        [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		DaysShort:   [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+		AM:          "AM", PM: "PM",
+		Decimal: ".", Thousands: ",",
+	}
+)
+
+// localeIDs основной язык кода [$-419] (две младшие шестнадцатеричные цифры)
+var localeIDs = map[string]*Locale{"19": &LocaleRU, "09": &LocaleEN}
+
+// builtinNumFmts коды встроенных числовых форматов Excel (даты - как в русской локали)
+var builtinNumFmts = map[int]string{
+	0: "General", 1: "0", 2: "0.00", 3: "#,##0", 4: "#,##0.00",
+	9: "0%", 10: "0.00%", 11: "0.00E+00", 12: "# ?/?", 13: "# ??/??",
+	14: "dd.mm.yyyy", 15: "d-mmm-yy", 16: "d-mmm", 17: "mmm-yy",
+	18: "h:mm AM/PM", 19: "h:mm:ss AM/PM", 20: "h:mm", 21: "h:mm:ss", 22: "dd.mm.yyyy h:mm",
+	37: "#,##0 ;(#,##0)", 38: "#,##0 ;[Red](#,##0)", 39: "#,##0.00;(#,##0.00)", 40: "#,##0.00;[Red](#,##0.00)",
+	45: "mm:ss", 46: "[h]:mm:ss", 47: "mm:ss.0", 48: "##0.0E+0", 49: "@",
+}
+
+// виды элементов формата
+const (
+	nfLiteral  = iota // текст как есть
+	nfGeneral         // General - число без формата
+	nfDigit           // 0 # ?
+	nfPoint           // десятичная точка
+	nfComma           // разделитель тысяч или деление на 1000
+	nfPercent         // %
+	nfExp             // E+ E-
+	nfText            // @ - текст ячейки
+	nfYear            // y
+	nfMonth           // m (месяц)
+	nfDay             // d
+	nfHour            // h
+	nfMinute          // m (минуты: после часов или перед секундами)
+	nfSecond          // s
+	nfSubsec          // .0 после секунд
+	nfAmPm            // AM/PM, A/P
+	nfElapsed         // [h] [mm] [ss] - прошедшее время
+	nfFraction        // дроби # ?/? (не поддерживаются, число выводится как General)
+)
+
+// nfToken элемент формата
+type nfToken struct {
+	kind int
+	text string // литерал, символ цифры, E+/E-, AM/PM или буквы даты (yyyy, mm, [h])
+	n    int    // кол-во букв даты
+}
+
+// nfSection секция формата (положительные; отрицательные; ноль; текст)
+type nfSection struct {
+	tokens  []nfToken
+	date    bool
+	ampm    bool
+	locale  *Locale
+	cond    string  // условие [>100]: оператор
+	condVal float64 // и значение
+}
+
+// NumFormat разобранный числовой формат Excel (код формата ячейки): до четырёх секций через ";" -
+// для положительных чисел, отрицательных, нуля и текста.
+// Используется для перевода формата даты в формат разбора Go и вывода значений как в Excel.
+type NumFormat struct {
+	Code     string
+	sections []nfSection
+}
+
+// ParseNumFormat разбор кода формата Excel: "dd.mm.yyyy hh:mm", "#,##0.00 ₽;[Red]-#,##0.00 ₽", "0.0%"
+func ParseNumFormat(code string) (*NumFormat, error) {
+	nf := &NumFormat{Code: code}
+	if strings.TrimSpace(code) == "" {
+		code = "General"
+	}
+	for _, part := range splitSections(code) {
+		sec, err := parseSection(part)
+		if err != nil {
+			return nil, fmt.Errorf("формат %q: %v", nf.Code, err)
+		}
+		nf.sections = append(nf.sections, sec)
+	}
+	if len(nf.sections) > 4 {
+		return nil, fmt.Errorf("формат %q: больше четырёх секций", nf.Code)
+	}
+	return nf, nil
+}
+
+// builtinNumFormat формат ячейки по номеру встроенного формата или коду пользовательского
+func builtinNumFormat(id int, code string) *NumFormat {
+	if code == "" {
+		code = builtinNumFmts[id]
+		if id >= 27 && id <= 36 || id >= 50 && id <= 58 { // встроенные даты восточноазиатских локалей
+			code = builtinNumFmts[14]
+		}
+	}
+	nf, err := ParseNumFormat(code)
+	if err != nil {
+		nf, _ = ParseNumFormat("General")
+	}
+	return nf
+}
+
+// splitSections делим формат на секции по ";" вне кавычек и скобок
+func splitSections(code string) []string {
+	var res []string
+	quoted, bracket, start := false, false, 0
+	for i := 0; i < len(code); i++ {
+		switch c := code[i]; {
+		case c == '\\' && !quoted:
+			i++
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '[':
+			bracket = true
+		case c == ']':
+			bracket = false
+		case c == ';' && !bracket:
+			res = append(res, code[start:i])
+			start = i + 1
+		}
+	}
+	return append(res, code[start:])
+}
+
+// parseSection разбор одной секции формата
+func parseSection(code string) (nfSection, error) {
+	var sec nfSection
+	add := func(kind int, text string, n int) {
+		// соседние литералы объединяем
+		if l := len(sec.tokens); kind == nfLiteral && l > 0 && sec.tokens[l-1].kind == nfLiteral {
+			sec.tokens[l-1].text += text
+			return
+		}
+		sec.tokens = append(sec.tokens, nfToken{kind: kind, text: text, n: n})
+	}
+	rs := []rune(code)
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		lower := toLowerASCII(r)
+		switch {
+		case r == '"':
+			end := i + 1
+			for end < len(rs) && rs[end] != '"' {
+				end++
+			}
+			if end >= len(rs) {
+				return sec, fmt.Errorf("нет закрывающей кавычки")
+			}
+			add(nfLiteral, string(rs[i+1:end]), 0)
+			i = end
+		case r == '\\' || r == '!':
+			if i+1 < len(rs) {
+				i++
+				add(nfLiteral, string(rs[i]), 0)
+			}
+		case r == '_': // отступ шириной символа
+			if i+1 < len(rs) {
+				i++
+				add(nfLiteral, " ", 0)
+			}
+		case r == '*': // заполнение ячейки символом - в тексте не выводится
+			i++
+		case r == '[':
+			end := i + 1
+			for end < len(rs) && rs[end] != ']' {
+				end++
+			}
+			if end >= len(rs) {
+				return sec, fmt.Errorf("нет закрывающей скобки ]")
+			}
+			if err := sec.bracket(string(rs[i+1:end]), add); err != nil {
+				return sec, err
+			}
+			i = end
+		case hasPrefixFold(rs[i:], "general"):
+			add(nfGeneral, "General", 0)
+			i += len("general") - 1
+		case hasPrefixFold(rs[i:], "am/pm"):
+			add(nfAmPm, "AM/PM", 0)
+			sec.ampm = true
+			i += len("am/pm") - 1
+		case hasPrefixFold(rs[i:], "a/p"):
+			add(nfAmPm, "A/P", 0)
+			sec.ampm = true
+			i += len("a/p") - 1
+		case lower == 'y' || lower == 'm' || lower == 'd' || lower == 'h' || lower == 's':
+			n := 1
+			for i+n < len(rs) && toLowerASCII(rs[i+n]) == lower {
+				n++
+			}
+			kind := map[rune]int{'y': nfYear, 'm': nfMonth, 'd': nfDay, 'h': nfHour, 's': nfSecond}[lower]
+			add(kind, strings.Repeat(string(lower), n), n)
+			sec.date = true
+			i += n - 1
+		case lower == 'e' && i+1 < len(rs) && (rs[i+1] == '+' || rs[i+1] == '-'):
+			add(nfExp, "E"+string(rs[i+1]), 0)
+			i++
+		case r == '0' || r == '#' || r == '?':
+			add(nfDigit, string(r), 0)
+		case r == '.':
+			if l := len(sec.tokens); l > 0 && sec.tokens[l-1].kind == nfSecond && i+1 < len(rs) && rs[i+1] == '0' {
+				n := 0
+				for i+1+n < len(rs) && rs[i+1+n] == '0' {
+					n++
+				}
+				add(nfSubsec, "."+strings.Repeat("0", n), n)
+				i += n
+				continue
+			}
+			add(nfPoint, ".", 0)
+		case r == ',':
+			add(nfComma, ",", 0)
+		case r == '%':
+			add(nfPercent, "%", 0)
+		case r == '@':
+			add(nfText, "@", 0)
+		case r == '/' && !sec.date && sec.hasDigits():
+			add(nfFraction, "/", 0)
+		default:
+			add(nfLiteral, string(r), 0)
+		}
+	}
+	sec.resolveMinutes()
+	if sec.date { // точка и запятая в дате - разделители
+		tokens := sec.tokens
+		sec.tokens = nil
+		for _, t := range tokens {
+			switch t.kind {
+			case nfPoint, nfComma:
+				add(nfLiteral, t.text, 0)
+			case nfDigit, nfPercent, nfExp, nfFraction, nfGeneral:
+				return sec, fmt.Errorf("элементы числа и даты в одной секции")
+			default:
+				add(t.kind, t.text, t.n)
+			}
+		}
+	}
+	return sec, nil
+}
+
+// bracket содержимое квадратных скобок: прошедшее время, язык и валюта, цвет, условие
+func (sec *nfSection) bracket(s string, add func(int, string, int)) error {
+	lower := strings.ToLower(s)
+	switch {
+	case lower != "" && strings.Trim(lower, string(lower[0])) == "" && strings.Contains("hms", lower[:1]):
+		add(nfElapsed, lower, len(lower))
+		sec.date = true
+	case strings.HasPrefix(s, "$"): // [$₽-419] - валюта и язык
+		cur, id, _ := strings.Cut(s[1:], "-")
+		if cur != "" {
+			add(nfLiteral, cur, 0)
+		}
+		if len(id) >= 2 { // основной язык - две младшие цифры кода: 419 и FC19 - русский
+			sec.locale = localeIDs[strings.ToUpper(id[len(id)-2:])]
+		}
+	case strings.ContainsAny(s[:1], "<>="):
+		op := s[:1]
+		if len(s) > 1 && strings.ContainsAny(s[1:2], "<>=") {
+			op = s[:2]
+		}
+		val, err := strconv.ParseFloat(strings.TrimSpace(s[len(op):]), 64)
+		if err != nil {
+			return fmt.Errorf("неверное условие [%v]", s)
+		}
+		sec.cond, sec.condVal = op, val
+	} // цвет ([Red], [Color10]) в тексте не выводится
+	return nil
+}
+
+// resolveMinutes m и mm - минуты, если стоят после часов или перед секундами
+func (sec *nfSection) resolveMinutes() {
+	prev := -1
+	for i, t := range sec.tokens {
+		if t.kind != nfMonth && t.kind != nfHour && t.kind != nfSecond && t.kind != nfElapsed &&
+			t.kind != nfDay && t.kind != nfYear {
+			continue
+		}
+		if t.kind == nfMonth && t.n <= 2 {
+			if prev >= 0 && (sec.tokens[prev].kind == nfHour || sec.tokens[prev].text[0] == 'h') {
+				sec.tokens[i].kind = nfMinute
+			} else if next := sec.nextDatePart(i); next >= 0 &&
+				(sec.tokens[next].kind == nfSecond || sec.tokens[next].text[0] == 's') {
+				sec.tokens[i].kind = nfMinute
+			}
+		}
+		prev = i
+	}
+}
+
+// nextDatePart номер следующего элемента даты или времени после i
+func (sec *nfSection) nextDatePart(i int) int {
+	for j := i + 1; j < len(sec.tokens); j++ {
+		switch sec.tokens[j].kind {
+		case nfYear, nfMonth, nfDay, nfHour, nfSecond, nfElapsed:
+			return j
+		}
+	}
+	return -1
+}
+
+// hasDigits в секции уже есть цифры числа
+func (sec *nfSection) hasDigits() bool {
+	for _, t := range sec.tokens {
+		if t.kind == nfDigit {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDate формат даты или времени (в первой секции есть элементы даты)
+func (nf *NumFormat) IsDate() bool {
+	return len(nf.sections) > 0 && nf.sections[0].date
+}
+
+// IsText формат текста (@)
+func (nf *NumFormat) IsText() bool {
+	if len(nf.sections) != 1 {
+		return false
+	}
+	for _, t := range nf.sections[0].tokens {
+		if t.kind == nfText {
+			return true
+		}
+	}
+	return false
+}
+
+// Layout формат разбора Go для формата даты Excel: dd.mm.yyyy hh:mm -> 02.01.2006 15:04,
+// d mmmm yyyy -> 2 January 2006. Прошедшее время ([h]:mm) и первая буква месяца (mmmmm) не поддерживаются,
+// как и литералы, совпадающие с элементами формата Go (цифры, Jan, Mon, PM).
+func (nf *NumFormat) Layout() (string, error) {
+	if !nf.IsDate() {
+		return "", fmt.Errorf("формат %q не является форматом даты или времени", nf.Code)
+	}
+	sec := nf.sections[0]
+	var sb strings.Builder
+	for _, t := range sec.tokens {
+		switch t.kind {
+		case nfLiteral:
+			if err := checkLayoutLiteral(t.text); err != nil {
+				return "", fmt.Errorf("формат %q: %v", nf.Code, err)
+			}
+			sb.WriteString(t.text)
+		case nfYear:
+			sb.WriteString(pick(t.n <= 2, "06", "2006"))
+		case nfMonth:
+			switch t.n {
+			case 1:
+				sb.WriteString("1")
+			case 2:
+				sb.WriteString("01")
+			case 3:
+				sb.WriteString("Jan")
+			case 4:
+				sb.WriteString("January")
+			default:
+				return "", fmt.Errorf("формат %q: первая буква месяца (mmmmm) не поддерживается для разбора", nf.Code)
+			}
+		case nfDay:
+			switch t.n {
+			case 1:
+				sb.WriteString("2")
+			case 2:
+				sb.WriteString("02")
+			case 3:
+				sb.WriteString("Mon")
+			default:
+				sb.WriteString("Monday")
+			}
+		case nfHour:
+			if sec.ampm {
+				sb.WriteString(pick(t.n == 1, "3", "03"))
+			} else {
+				sb.WriteString("15")
+			}
+		case nfMinute:
+			sb.WriteString(pick(t.n == 1, "4", "04"))
+		case nfSecond:
+			sb.WriteString(pick(t.n == 1, "5", "05"))
+		case nfSubsec:
+			sb.WriteString("." + strings.Repeat("0", t.n))
+		case nfAmPm:
+			if t.text != "AM/PM" {
+				return "", fmt.Errorf("формат %q: A/P не поддерживается для разбора", nf.Code)
+			}
+			sb.WriteString("PM")
+		case nfElapsed:
+			return "", fmt.Errorf("формат %q: прошедшее время %v не поддерживается для разбора", nf.Code, "["+t.text+"]")
+		case nfText:
+			return "", fmt.Errorf("формат %q: @ не поддерживается для разбора", nf.Code)
+		}
+	}
+	return sb.String(), nil
+}
+
+// checkLayoutLiteral текст формата не должен читаться Go как элемент даты
+func checkLayoutLiteral(s string) error {
+	if strings.ContainsAny(s, "0123456789") {
+		return fmt.Errorf("цифры в тексте %q не поддерживаются для разбора", s)
+	}
+	for _, word := range []string{"Jan", "Mon", "MST", "PM", "pm", "Z07", "_2"} {
+		if strings.Contains(s, word) {
+			return fmt.Errorf("текст %q совпадает с элементом формата Go %v", s, word)
+		}
+	}
+	return nil
+}
+
+// excelLayout формат разбора Go по коду формата даты Excel
+func excelLayout(format string) (string, error) {
+	nf, err := ParseNumFormat(format)
+	if err != nil {
+		return "", err
+	}
+	return nf.Layout()
+}
+
+// section секция формата для числа: по условиям или знаку (отрицательные, ноль)
+func (nf *NumFormat) section(val float64) (nfSection, bool) {
+	secs := nf.sections
+	if secs[len(secs)-1].isText() && len(secs) > 1 {
+		secs = secs[:len(secs)-1]
+	}
+	if secs[0].cond != "" { // условные секции: [>100]0;[<0]-0;0
+		for i, sec := range secs {
+			if sec.cond == "" || sec.match(val) {
+				return sec, i == 0 && val < 0 && sec.cond == ""
+			}
+		}
+		return secs[len(secs)-1], false
+	}
+	switch {
+	case val < 0 && len(secs) > 1:
+		return secs[1], false
+	case val == 0 && len(secs) > 2:
+		return secs[2], false
+	}
+	return secs[0], val < 0 // минус выводится, только если нет секции отрицательных
+}
+
+// match число удовлетворяет условию секции
+func (sec nfSection) match(val float64) bool {
+	switch sec.cond {
+	case "<":
+		return val < sec.condVal
+	case "<=":
+		return val <= sec.condVal
+	case ">":
+		return val > sec.condVal
+	case ">=":
+		return val >= sec.condVal
+	case "=":
+		return val == sec.condVal
+	case "<>":
+		return val != sec.condVal
+	}
+	return false
+}
+
+// isText секция для текста (содержит @)
+func (sec nfSection) isText() bool {
+	for _, t := range sec.tokens {
+		if t.kind == nfText {
+			return true
+		}
+	}
+	return false
+}
+
+// Format число как его показывает Excel с этим форматом (серийный номер - как дата системы 1900)
+func (nf *NumFormat) Format(val float64) string {
+	return nf.format(val, false)
+}
+
+// format число по формату с учётом системы дат книги
+func (nf *NumFormat) format(val float64, date1904 bool) string {
+	sec, minus := nf.section(val)
+	loc := &LocaleRU
+	if sec.locale != nil {
+		loc = sec.locale
+	}
+	if sec.date {
+		res, err := sec.formatDate(val, date1904, loc)
+		if err != nil {
+			return strings.Repeat("#", 8) // Excel показывает ##### для недопустимых дат
+		}
+		return res
+	}
+	signed := math.Abs(val) // в секциях отрицательных чисел знак задаёт сам формат
+	if minus {
+		signed = -signed
+	}
+	if sec.hasKind(nfFraction) { // дроби не поддерживаются - число как General
+		return sec.formatGeneral(signed, loc)
+	}
+	if !sec.hasDigits() {
+		if sec.hasKind(nfGeneral) || len(sec.tokens) == 0 || sec.isText() { // формат @ - число выводится как General
+			return sec.formatGeneral(signed, loc)
+		}
+		return sec.literals()
+	}
+	res := sec.formatNumber(math.Abs(val), loc)
+	if minus && strings.ContainsAny(res, "123456789") {
+		res = "-" + res
+	}
+	return res
+}
+
+// FormatText текст как его показывает Excel: секция текста формата (@) или текст как есть
+func (nf *NumFormat) FormatText(s string) string {
+	last := nf.sections[len(nf.sections)-1]
+	if !last.isText() {
+		return s
+	}
+	var sb strings.Builder
+	for _, t := range last.tokens {
+		switch t.kind {
+		case nfText:
+			sb.WriteString(s)
+		case nfLiteral:
+			sb.WriteString(t.text)
+		}
+	}
+	return sb.String()
+}
+
+// hasKind в секции есть элемент вида kind
+func (sec nfSection) hasKind(kind int) bool {
+	for _, t := range sec.tokens {
+		if t.kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// literals только текст секции (формат без цифр: "-" для нуля)
+func (sec nfSection) literals() string {
+	var sb strings.Builder
+	for _, t := range sec.tokens {
+		if t.kind == nfLiteral {
+			sb.WriteString(t.text)
+		}
+	}
+	return sb.String()
+}
+
+// formatGeneral число в формате General: до 11 знаков, большие и малые числа - с экспонентой
+func (sec nfSection) formatGeneral(val float64, loc *Locale) string {
+	abs := math.Abs(val)
+	var num string
+	if abs != 0 && (abs >= 1e11 || abs < 1e-9) {
+		num = strconv.FormatFloat(val, 'E', 5, 64)
+		mant, exp, _ := strings.Cut(num, "E")
+		if strings.Contains(mant, ".") {
+			mant = strings.TrimRight(strings.TrimRight(mant, "0"), ".")
+		}
+		if len(exp) == 2 { // E+5 -> E+05
+			exp = exp[:1] + "0" + exp[1:]
+		}
+		num = mant + "E" + exp
+	} else {
+		intLen := len(strconv.FormatFloat(math.Trunc(abs), 'f', 0, 64))
+		prec := 10 - intLen
+		if prec < 0 {
+			prec = 0
+		}
+		num = strconv.FormatFloat(val, 'f', prec, 64)
+		if strings.Contains(num, ".") {
+			num = strings.TrimRight(strings.TrimRight(num, "0"), ".")
+		}
+	}
+	num = strings.Replace(num, ".", loc.Decimal, 1)
+	// число выводится вместо всех элементов числа, текст между ними (пробел в "# ?/?") отбрасывается
+	first, last := -1, -1
+	for i, t := range sec.tokens {
+		switch t.kind {
+		case nfGeneral, nfFraction, nfDigit:
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	var sb strings.Builder
+	general := false
+	for i, t := range sec.tokens {
+		switch t.kind {
+		case nfLiteral:
+			if i < first || i > last {
+				sb.WriteString(t.text)
+			}
+		case nfGeneral, nfFraction, nfDigit:
+			if !general {
+				sb.WriteString(num)
+				general = true
+			}
+		}
+	}
+	if !general {
+		sb.WriteString(num)
+	}
+	return sb.String()
+}
+
+// formatNumber неотрицательное число по элементам секции: 0 # ? , . % E+
+func (sec nfSection) formatNumber(val float64, loc *Locale) string {
+	var intPh, fracPh, expPh []int // номера элементов цифр
+	point, exp, lastInt := -1, -1, -1
+	thousands, scale := false, 0
+	for i, t := range sec.tokens {
+		switch t.kind {
+		case nfDigit:
+			switch {
+			case exp >= 0:
+				expPh = append(expPh, i)
+			case point >= 0:
+				fracPh = append(fracPh, i)
+			default:
+				intPh = append(intPh, i)
+				lastInt = i
+			}
+		case nfPoint:
+			if point < 0 && exp < 0 {
+				point = i
+			}
+		case nfExp:
+			if exp < 0 {
+				exp = i
+			}
+		case nfPercent:
+			val *= 100
+		}
+	}
+	// запятая между цифрами целой части - разделитель тысяч, запятые после последней цифры - деление на 1000
+	lastDigit := lastInt
+	if len(fracPh) > 0 {
+		lastDigit = fracPh[len(fracPh)-1]
+	}
+	for i, t := range sec.tokens {
+		if t.kind != nfComma || (exp >= 0 && i > exp) {
+			continue
+		}
+		switch {
+		case len(intPh) > 0 && i > intPh[0] && i < lastInt:
+			thousands = true
+		case lastDigit >= 0 && i > lastDigit:
+			scale++
+		}
+	}
+	for ; scale > 0; scale-- {
+		val /= 1000
+	}
+
+	expValue := 0
+	if exp >= 0 && val != 0 {
+		n := len(intPh)
+		if n == 0 {
+			n = 1
+		}
+		expValue = int(math.Floor(math.Log10(val))) - (n - 1)
+		if len(intPh) > 1 && strings.HasPrefix(sec.numberPattern(intPh), "#") { // инженерный формат ##0.0E+0
+			expValue = int(math.Floor(math.Log10(val)))
+			expValue -= ((expValue % n) + n) % n
+		}
+		val /= math.Pow(10, float64(expValue))
+		if r := formatFixed(val, len(fracPh)); strings.HasPrefix(r, "10") && len(intPh) <= 1 {
+			val /= 10
+			expValue++
+		}
+	}
+
+	num := formatFixed(val, len(fracPh))
+	intDigits, fracDigits, _ := strings.Cut(num, ".")
+	if intDigits == "0" {
+		intDigits = ""
+	}
+
+	out := make([]string, len(sec.tokens))
+	// целая часть: цифры заполняют позиции справа налево, лишние цифры - в первую позицию
+	if thousands {
+		minLen := 0
+		for _, i := range intPh {
+			if sec.tokens[i].text == "0" {
+				minLen++
+			}
+		}
+		for len(intDigits) < minLen {
+			intDigits = "0" + intDigits
+		}
+		if len(intPh) > 0 {
+			out[intPh[0]] = groupThousands(intDigits, loc.Thousands)
+		}
+	} else {
+		d := intDigits
+		for k := len(intPh) - 1; k >= 0; k-- {
+			i := intPh[k]
+			switch {
+			case k == 0 && d != "":
+				out[i] = d
+			case d != "":
+				out[i], d = d[len(d)-1:], d[:len(d)-1]
+			default:
+				out[i] = placeholder(sec.tokens[i].text)
+			}
+		}
+	}
+	// дробная часть: незначащие нули для # убираются, для ? заменяются пробелами
+	trim := true
+	for k := len(fracPh) - 1; k >= 0; k-- {
+		i, digit := fracPh[k], fracDigits[k:k+1]
+		if trim && digit == "0" && sec.tokens[i].text != "0" {
+			out[i] = placeholder(sec.tokens[i].text)
+			continue
+		}
+		trim = false
+		out[i] = digit
+	}
+	// экспонента
+	if exp >= 0 {
+		sign := ""
+		switch {
+		case expValue < 0:
+			sign = "-"
+		case sec.tokens[exp].text == "E+":
+			sign = "+"
+		}
+		out[exp] = "E" + sign
+		e := strconv.Itoa(abs(expValue))
+		for len(e) < len(expPh) {
+			e = "0" + e
+		}
+		if len(expPh) > 0 {
+			out[expPh[0]] = e
+		}
+	}
+
+	var sb strings.Builder
+	for i, t := range sec.tokens {
+		switch t.kind {
+		case nfLiteral:
+			sb.WriteString(t.text)
+		case nfDigit, nfExp:
+			sb.WriteString(out[i])
+		case nfPoint:
+			if i == point {
+				sb.WriteString(loc.Decimal)
+			} else {
+				sb.WriteString(".")
+			}
+		case nfPercent:
+			sb.WriteString("%")
+		}
+	}
+	return sb.String()
+}
+
+// formatFixed неотрицательное число с prec знаками после запятой, как округляет Excel:
+// число приводится к 15 значащим цифрам, затем половина округляется от нуля (2,675 -> 2,68, 2,5 -> 3)
+func formatFixed(val float64, prec int) string {
+	if math.IsInf(val, 0) || math.IsNaN(val) {
+		return strconv.FormatFloat(val, 'f', prec, 64)
+	}
+	mant, expStr, _ := strings.Cut(strconv.FormatFloat(val, 'e', excelDigits-1, 64), "e")
+	exp, _ := strconv.Atoi(expStr)
+	digits := strings.Replace(mant, ".", "", 1)
+	intLen := exp + 1 // цифр до запятой
+	if val == 0 {
+		digits, intLen = "0", 1
+	}
+	if intLen < 0 {
+		digits = strings.Repeat("0", -intLen) + digits
+		intLen = 0
+	}
+	for len(digits) <= intLen+prec {
+		digits += "0"
+	}
+	res := []byte(digits[:intLen+prec])
+	if digits[intLen+prec] >= '5' {
+		i := len(res) - 1
+		for ; i >= 0 && res[i] == '9'; i-- {
+			res[i] = '0'
+		}
+		if i >= 0 {
+			res[i]++
+		} else {
+			res = append([]byte{'1'}, res...)
+			intLen++
+		}
+	}
+	intPart := strings.TrimLeft(string(res[:intLen]), "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+	if prec == 0 {
+		return intPart
+	}
+	return intPart + "." + string(res[intLen:])
+}
+
+// numberPattern символы цифр целой части
+func (sec nfSection) numberPattern(ph []int) string {
+	var sb strings.Builder
+	for _, i := range ph {
+		sb.WriteString(sec.tokens[i].text)
+	}
+	return sb.String()
+}
+
+// placeholder вывод позиции цифры без значащей цифры: 0 - ноль, ? - пробел, # - ничего
+func placeholder(ph string) string {
+	switch ph {
+	case "0":
+		return "0"
+	case "?":
+		return " "
+	}
+	return ""
+}
+
+// groupThousands разделяем цифры на группы по три
+func groupThousands(digits, sep string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+	var sb strings.Builder
+	first := len(digits) % 3
+	if first > 0 {
+		sb.WriteString(digits[:first])
+	}
+	for i := first; i < len(digits); i += 3 {
+		if sb.Len() > 0 {
+			sb.WriteString(sep)
+		}
+		sb.WriteString(digits[i : i+3])
+	}
+	return sb.String()
+}
+
+// formatDate серийный номер как дата или время по элементам секции
+func (sec nfSection) formatDate(val float64, date1904 bool, loc *Locale) (string, error) {
+	subsec := 0
+	for _, t := range sec.tokens {
+		if t.kind == nfSubsec {
+			subsec = t.n
+		}
+	}
+	// без долей секунды время округляется до секунд
+	unit := math.Pow(10, float64(subsec)) * 86400
+	rounded := math.Round(val*unit) / unit
+	t, err := excelSerialToTime(rounded, date1904, time.UTC)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	day := false // число уже выведено: месяц в родительном падеже
+	for _, tok := range sec.tokens {
+		switch tok.kind {
+		case nfLiteral:
+			sb.WriteString(tok.text)
+		case nfYear:
+			if tok.n <= 2 {
+				sb.WriteString(fmt.Sprintf("%02d", t.Year()%100))
+			} else {
+				sb.WriteString(fmt.Sprintf("%04d", t.Year()))
+			}
+		case nfMonth:
+			switch tok.n {
+			case 1:
+				sb.WriteString(strconv.Itoa(int(t.Month())))
+			case 2:
+				sb.WriteString(fmt.Sprintf("%02d", int(t.Month())))
+			case 3:
+				sb.WriteString(loc.MonthsShort[t.Month()-1])
+			case 4:
+				if day && loc.MonthsOf[t.Month()-1] != "" {
+					sb.WriteString(loc.MonthsOf[t.Month()-1])
+				} else {
+					sb.WriteString(loc.Months[t.Month()-1])
+				}
+			default:
+				r, _ := utf8.DecodeRuneInString(loc.Months[t.Month()-1])
+				sb.WriteRune(r)
+			}
+		case nfDay:
+			switch tok.n {
+			case 1:
+				sb.WriteString(strconv.Itoa(t.Day()))
+				day = true
+			case 2:
+				sb.WriteString(fmt.Sprintf("%02d", t.Day()))
+				day = true
+			case 3:
+				sb.WriteString(loc.DaysShort[t.Weekday()])
+			default:
+				sb.WriteString(loc.Days[t.Weekday()])
+			}
+		case nfHour:
+			h := t.Hour()
+			if sec.ampm {
+				h %= 12
+				if h == 0 {
+					h = 12
+				}
+			}
+			sb.WriteString(pad(h, tok.n))
+		case nfMinute:
+			sb.WriteString(pad(t.Minute(), tok.n))
+		case nfSecond:
+			sb.WriteString(pad(t.Second(), tok.n))
+		case nfSubsec:
+			ms := fmt.Sprintf("%03d", t.Nanosecond()/int(time.Millisecond))
+			if tok.n < 3 {
+				ms = ms[:tok.n]
+			}
+			sb.WriteString(loc.Decimal + ms)
+		case nfAmPm:
+			am, pm := loc.AM, loc.PM
+			if tok.text == "A/P" {
+				am, pm = am[:1], pm[:1]
+			}
+			sb.WriteString(pick(t.Hour() < 12, am, pm))
+		case nfElapsed:
+			units := map[byte]float64{'h': 24, 'm': 1440, 's': 86400}[tok.text[0]]
+			sb.WriteString(pad(int(math.Floor(rounded*units+1e-9)), tok.n))
+		}
+	}
+	return sb.String(), nil
+}
+
+// cellFormats форматы ячеек книги по номеру стиля (для вывода значений как в Excel).
+// Форматы разбираются один раз на стиль, в книге их обычно десятки.
+type cellFormats struct {
+	f        *excelize.File
+	date1904 bool
+	byStyle  map[int]*NumFormat
+}
+
+func newCellFormats(f *excelize.File) *cellFormats {
+	cf := &cellFormats{f: f, byStyle: make(map[int]*NumFormat)}
+	if props, err := f.GetWorkbookProps(); err == nil && props.Date1904 != nil {
+		cf.date1904 = *props.Date1904
+	}
+	return cf
+}
+
+// numFormat формат ячеек стиля styleID
+func (cf *cellFormats) numFormat(styleID int) *NumFormat {
+	nf, ok := cf.byStyle[styleID]
+	if !ok {
+		nf = builtinNumFormat(cellNumFmt(cf.f, styleID))
+		cf.byStyle[styleID] = nf
+	}
+	return nf
+}
+
+// display значение ячейки (raw - значение без форматирования, attr - стиль и тип ячейки) как его показывает Excel
+func (cf *cellFormats) display(attr cellAttr, raw string) string {
+	if raw == "" {
+		return ""
+	}
+	nf := cf.numFormat(attr.style)
+	switch attr.typ {
+	case "b":
+		return pick(raw == "1", "TRUE", "FALSE")
+	case "", "n":
+		if val, err := strconv.ParseFloat(raw, 64); err == nil {
+			return nf.format(val, cf.date1904)
+		}
+	case "e":
+		return raw
+	}
+	return nf.FormatText(raw)
+}
+
+// pad число с ведущими нулями до двух знаков, если в формате две буквы
+func pad(n, letters int) string {
+	if letters >= 2 {
+		return fmt.Sprintf("%02d", n)
+	}
+	return strconv.Itoa(n)
+}
+
+// pick выбор строки по условию
+func pick(cond bool, yes, no string) string {
+	if cond {
+		return yes
+	}
+	return no
+}
+
+// toLowerASCII латинская буква в нижнем регистре
+func toLowerASCII(r rune) rune {
+	if r >= 'A' && r <= 'Z' {
+		return r + 'a' - 'A'
+	}
+	return r
+}
+
+// hasPrefixFold начало текста совпадает с prefix без учёта регистра (латиница)
+func hasPrefixFold(rs []rune, prefix string) bool {
+	if len(rs) < len(prefix) {
+		return false
+	}
+	for i := 0; i < len(prefix); i++ {
+		if toLowerASCII(rs[i]) != rune(prefix[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
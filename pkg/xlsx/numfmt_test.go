@@ -0,0 +1,172 @@
+package xlsx
+
+import "testing"
+
+func TestNumFormatLayout(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"dd.mm.yyyy", "02.01.2006"},
+		{"dd.mm.yyyy hh:mm:ss", "02.01.2006 15:04:05"},
+		{"d mmmm yyyy", "2 January 2006"},
+		{"[$-409]d mmmm yyyy, dddd", "2 January 2006, Monday"},
+		{"mmm-yy", "Jan-06"},
+		{"h:mm AM/PM", "3:04 PM"},
+		{"hh:mm", "15:04"},
+		{"mm:ss.0", "04:05.0"},
+		{"m/d/yy", "1/2/06"},
+		{`yyyy-mm-dd"T"hh:mm`, "2006-01-02T15:04"},
+		{`[Red]dd.mm.yyyy;@`, "02.01.2006"},
+	}
+	for _, tt := range tests {
+		nf, err := ParseNumFormat(tt.code)
+		if err != nil {
+			t.Errorf("ParseNumFormat(%q): %v", tt.code, err)
+			continue
+		}
+		got, err := nf.Layout()
+		if err != nil {
+			t.Errorf("Layout(%q): %v", tt.code, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Layout(%q) = %q, ожидалось %q", tt.code, got, tt.want)
+		}
+	}
+
+	// форматы, которые нельзя перевести в формат разбора Go
+	for _, code := range []string{"#,##0.00", "[h]:mm:ss", "mmmmm yy", "h:mm A/P", "@"} {
+		nf, err := ParseNumFormat(code)
+		if err != nil {
+			t.Errorf("ParseNumFormat(%q): %v", code, err)
+			continue
+		}
+		if got, err := nf.Layout(); err == nil {
+			t.Errorf("Layout(%q) = %q, ожидалась ошибка", code, got)
+		}
+	}
+}
+
+func TestNumFormatFormat(t *testing.T) {
+	tests := []struct {
+		code string
+		val  float64
+		want string
+	}{
+		// числа, разделители тысяч, проценты
+		{"General", 1.0 / 3, "0,333333333"},
+		{"General", 123456789012, "1,23457E+11"},
+		{"General", -5, "-5"},
+		{"0", 42, "42"},
+		{"000000", 42, "000042"},
+		{"#,##0.00", 1234567.891, "1\u00a0234\u00a0567,89"},
+		{"#,##0.00", -1234.5, "-1\u00a0234,50"},
+		{"0.0#", 1.5, "1,5"},
+		{"#.##", 0.5, ",5"},
+		{"000-00-00", 1234567, "123-45-67"},
+		{"0.00%", 0.1534, "15,34%"},
+		{"0%", 0.5, "50%"},
+		{"@", 12, "12"},
+
+		// округление половины от нуля, как в Excel
+		{"0", 2.5, "3"},
+		{"0", 3.5, "4"},
+		{"0", -2.5, "-3"},
+		{"0", 0.4, "0"},
+		{"0.00", 2.675, "2,68"},
+		{"0.00", 1.005, "1,01"},
+		{"0.0", 0.05, "0,1"},
+		{"#,##0", 999.5, "1\u00a0000"},
+		{"0.00", 0.004, "0,00"},
+
+		// деление на 1000 запятыми после цифр
+		{`#,##0,"K"`, 1234567, "1\u00a0235K"},
+		{"0.0,,", 1234567, "1,2"},
+
+		// экспонента и инженерный формат
+		{"0.00E+00", 12345, "1,23E+04"},
+		{"0.00E+00", 0.00012345, "1,23E-04"},
+		{"0.0E+0", 9.96, "1,0E+1"},
+		{"##0.0E+0", 12345, "12,3E+3"},
+
+		// секции и условия
+		{`#,##0.00 "₽";[Red]-#,##0.00 "₽"`, -1500, "-1\u00a0500,00 ₽"},
+		{`#,##0;(#,##0);"-"`, 0, "-"},
+		{`#,##0;(#,##0);"-"`, -42, "(42)"},
+		{"General;(General)", -5, "(5)"},
+		{`[>100]"big";"small"`, 150, "big"},
+		{`[>100]"big";"small"`, 50, "small"},
+		{`[<0]"минус";[=0]"ноль";0`, 0, "ноль"},
+		{`[<0]"минус";[=0]"ноль";0`, 7, "7"},
+
+		// дроби не поддерживаются - число как General
+		{"# ?/?", 1.5, "1,5"},
+		{"# ??/??", 0.25, "0,25"},
+
+		// даты и время
+		{"dd.mm.yyyy", 45000, "15.03.2023"},
+		{"dd.mm.yyyy hh:mm:ss", 45000.5, "15.03.2023 12:00:00"},
+		{`yyyy-mm-dd"T"hh:mm`, 45000.1, "2023-03-15T02:24"},
+		{"hh:mm", 0.25, "06:00"},
+		{"h:mm AM/PM", 45000.75, "6:00 PM"},
+		{"mm:ss.0", 0.00123, "01:46,3"},
+		{"m/d/yy", 45000, "3/15/23"},
+
+		// прошедшее время
+		{"[h]:mm:ss", 1.5, "36:00:00"},
+		{"[mm]:ss", 0.5 / 24, "30:00"},
+
+		// языки: по умолчанию русский (месяц с числом - в родительном падеже), [$-409] - английский
+		{"d mmmm yyyy", 45000, "15 марта 2023"},
+		{"mmmm yyyy", 45000, "Март 2023"},
+		{"mmm-yy", 45000, "мар-23"},
+		{"[$-409]d mmmm yyyy, dddd", 45000, "15 March 2023, Wednesday"},
+		{"[$-419]dddd", 45000, "среда"},
+	}
+	for _, tt := range tests {
+		nf, err := ParseNumFormat(tt.code)
+		if err != nil {
+			t.Errorf("ParseNumFormat(%q): %v", tt.code, err)
+			continue
+		}
+		if got := nf.Format(tt.val); got != tt.want {
+			t.Errorf("Format(%q, %v) = %q, ожидалось %q", tt.code, tt.val, got, tt.want)
+		}
+	}
+}
+
+func TestBuiltinNumFormat(t *testing.T) {
+	tests := []struct {
+		id   int
+		val  float64
+		want string
+	}{
+		{0, 1234.5, "1234,5"},
+		{2, 1234.5, "1234,50"},
+		{4, 1234.5, "1\u00a0234,50"},
+		{12, 1.5, "1,5"},
+		{13, 0.25, "0,25"},
+		{14, 45000, "15.03.2023"},
+		{22, 45000.5, "15.03.2023 12:00"},
+		{31, 45000, "15.03.2023"},
+	}
+	for _, tt := range tests {
+		if got := builtinNumFormat(tt.id, "").Format(tt.val); got != tt.want {
+			t.Errorf("встроенный формат %d: Format(%v) = %q, ожидалось %q", tt.id, tt.val, got, tt.want)
+		}
+	}
+}
+
+func TestNumFormatText(t *testing.T) {
+	nf, err := ParseNumFormat(`0;-0;0;"Текст: "@`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := nf.FormatText("abc"); got != "Текст: abc" {
+		t.Errorf("FormatText = %q, ожидалось %q", got, "Текст: abc")
+	}
+	if got := nf.Format(-3); got != "-3" {
+		t.Errorf("Format(-3) = %q, ожидалось %q", got, "-3")
+	}
+}
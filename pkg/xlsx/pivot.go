@@ -0,0 +1,205 @@
+package xlsx
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// PivotField поле сводной таблицы
+type PivotField struct {
+	Data            string `json:"data"`                       // заголовок колонки исходных данных
+	Name            string `json:"name,omitempty"`             // подпись поля в сводной таблице
+	Subtotal        string `json:"subtotal,omitempty"`         // функция для полей данных: sum, count, average, max, min, product, countNums, stdDev, stdDevp, var, varp
+	DefaultSubtotal bool   `json:"default_subtotal,omitempty"` // промежуточные итоги по полю строк/колонок
+	Compact         bool   `json:"compact,omitempty"`          // сжатая форма поля
+	Outline         bool   `json:"outline,omitempty"`          // структурная форма поля
+}
+
+// PivotSettings описание сводной таблицы.
+// Диапазоны исходных данных и сводной таблицы вычисляются по заполненной части листа SourceSheet.
+type PivotSettings struct {
+	SourceSheet string       `json:"source_sheet,omitempty"` // лист с исходными данными (строка заголовка - первая непустая)
+	Sheet       string       `json:"sheet"`                  // лист сводной таблицы (пересоздаётся)
+	Cell        string       `json:"cell,omitempty"`         // левая верхняя ячейка сводной таблицы (по умолчанию A3)
+	Rows        []PivotField `json:"rows"`
+	Columns     []PivotField `json:"columns,omitempty"`
+	Filters     []PivotField `json:"filters,omitempty"`
+	Data        []PivotField `json:"data"`
+
+	RowGrandTotals *bool  `json:"row_grand_totals,omitempty"` // общие итоги по строкам (по умолчанию true)
+	ColGrandTotals *bool  `json:"col_grand_totals,omitempty"` // общие итоги по колонкам (по умолчанию true)
+	CompactData    bool   `json:"compact_data,omitempty"`     // значения в сжатом виде
+	MergeItem      bool   `json:"merge_item,omitempty"`       // объединять ячейки подписей
+	ShowRowStripes bool   `json:"show_row_stripes,omitempty"` // чередование строк
+	ShowColStripes bool   `json:"show_col_stripes,omitempty"` // чередование колонок
+	Style          string `json:"style,omitempty"`            // стиль сводной таблицы, например PivotStyleLight16
+}
+
+var pivotSubtotals = []string{"average", "count", "countNums", "max", "min", "product", "stdDev", "stdDevp", "sum", "var", "varp"}
+
+// AddPivotTable создаём в книге сводную таблицу по описанию p
+func (wb *Workbook) AddPivotTable(p PivotSettings) error {
+	if p.SourceSheet == "" {
+		return fmt.Errorf("сводная таблица %v: не задан лист исходных данных", p.Sheet)
+	}
+	if p.Sheet == "" {
+		return fmt.Errorf("сводная таблица по листу %v: не задан лист сводной таблицы", p.SourceSheet)
+	}
+	if len(p.Data) == 0 {
+		return fmt.Errorf("сводная таблица %v: не заданы поля данных", p.Sheet)
+	}
+	if p.Cell == "" {
+		p.Cell = "A3"
+	}
+
+	dataRange, headers, countData, err := wb.sheetDataRange(p.SourceSheet)
+	if err != nil {
+		return fmt.Errorf("сводная таблица %v: %w", p.Sheet, err)
+	}
+	opts := &excelize.PivotTableOptions{
+		DataRange:         dataRange,
+		RowGrandTotals:    true,
+		ColGrandTotals:    true,
+		ShowDrill:         true,
+		ShowRowHeaders:    true,
+		ShowColHeaders:    true,
+		ShowLastColumn:    true,
+		UseAutoFormatting: true,
+		PageOverThenDown:  true,
+
+		CompactData:         p.CompactData,
+		MergeItem:           p.MergeItem,
+		ShowRowStripes:      p.ShowRowStripes,
+		ShowColStripes:      p.ShowColStripes,
+		PivotTableStyleName: p.Style,
+	}
+	if p.RowGrandTotals != nil {
+		opts.RowGrandTotals = *p.RowGrandTotals
+	}
+	if p.ColGrandTotals != nil {
+		opts.ColGrandTotals = *p.ColGrandTotals
+	}
+	for _, list := range []struct {
+		name   string
+		fields []PivotField
+		dst    *[]excelize.PivotTableField
+	}{
+		{"rows", p.Rows, &opts.Rows},
+		{"columns", p.Columns, &opts.Columns},
+		{"filters", p.Filters, &opts.Filter},
+		{"data", p.Data, &opts.Data},
+	} {
+		for _, fld := range list.fields {
+			if !headers[fld.Data] {
+				return fmt.Errorf("сводная таблица %v: поле %v %q не найдено в заголовке листа %v", p.Sheet, list.name, fld.Data, p.SourceSheet)
+			}
+			if fld.Subtotal != "" && !inList(pivotSubtotals, fld.Subtotal) {
+				return fmt.Errorf("сводная таблица %v: поле %q: неизвестная функция %q (допустимы: %v)",
+					p.Sheet, fld.Data, fld.Subtotal, strings.Join(pivotSubtotals, ", "))
+			}
+			*list.dst = append(*list.dst, excelize.PivotTableField{
+				Data: fld.Data, Name: fld.Name, Subtotal: fld.Subtotal,
+				DefaultSubtotal: fld.DefaultSubtotal, Compact: fld.Compact, Outline: fld.Outline,
+			})
+		}
+	}
+
+	// размер сводной таблицы уточнит Excel при обновлении, задаём с запасом по числу записей
+	col, row, err := excelize.CellNameToCoordinates(p.Cell)
+	if err != nil {
+		return fmt.Errorf("сводная таблица %v: ячейка %v: %w", p.Sheet, p.Cell, err)
+	}
+	width := len(p.Rows) + len(p.Columns) + len(p.Data)
+	end, _ := excelize.CoordinatesToCellName(col+width-1, row+countData+1)
+	opts.PivotTableRange = fmt.Sprintf("%s!$%s:$%s", p.Sheet, absCell(p.Cell), absCell(end))
+
+	if err = wb.newSheet(p.Sheet); err != nil {
+		return err
+	}
+	if err = wb.f.AddPivotTable(opts); err != nil {
+		return fmt.Errorf("сводная таблица %v: %w", p.Sheet, err)
+	}
+	wb.f.SetActiveSheet(0)
+	return nil
+}
+
+// AddPivotTablesFile создаём в Excel-файле сводные таблицы по описаниям pivots
+func AddPivotTablesFile(filename string, pivots []PivotSettings, opts SaveOptions) error {
+	wb, err := OpenWorkbook(filename)
+	if err != nil {
+		return err
+	}
+	defer wb.Close()
+	wb.SetSaveOptions(opts)
+
+	for _, p := range pivots {
+		if err = wb.AddPivotTable(p); err != nil {
+			return err
+		}
+	}
+	return wb.Save()
+}
+
+// sheetDataRange диапазон заполненной части листа: от первой непустой строки (заголовок)
+// до последней строки по самой широкой строке. Возвращает также заголовки и кол-во строк данных.
+func (wb *Workbook) sheetDataRange(sheet string) (string, map[string]bool, int, error) {
+	if index, _ := wb.f.GetSheetIndex(sheet); index == -1 {
+		return "", nil, 0, fmt.Errorf("лист %v не найден", sheet)
+	}
+	rows, err := wb.f.Rows(sheet)
+	if err != nil {
+		return "", nil, 0, fmt.Errorf("f.Rows %v", err)
+	}
+	defer rows.Close()
+
+	var (
+		headers            map[string]bool
+		headerRow, lastRow int
+		maxColumn, rowNum  int
+	)
+	for rows.Next() {
+		rowNum++
+		row, err := rows.Columns(excelize.Options{RawCellValue: true})
+		if err != nil {
+			return "", nil, 0, fmt.Errorf("rows.Columns %v", err)
+		}
+		if len(row) == 0 {
+			continue
+		}
+		if headers == nil {
+			headerRow = rowNum
+			headers = make(map[string]bool, len(row))
+			for _, h := range row {
+				headers[h] = true
+			}
+		}
+		lastRow = rowNum
+		if len(row) > maxColumn {
+			maxColumn = len(row)
+		}
+	}
+	if headers == nil {
+		return "", nil, 0, fmt.Errorf("лист %v пуст", sheet)
+	}
+	letterLastColumn, _ := excelize.ColumnNumberToName(maxColumn)
+	dataRange := fmt.Sprintf("%s!$A$%d:$%s$%d", sheet, headerRow, letterLastColumn, lastRow)
+	return dataRange, headers, lastRow - headerRow, nil
+}
+
+// absCell абсолютный адрес ячейки: B5 -> B$5
+func absCell(cell string) string {
+	col, row, _ := excelize.SplitCellName(cell)
+	return fmt.Sprintf("%s$%d", col, row)
+}
+
+// inList есть ли значение в списке (без учёта регистра)
+func inList(list []string, val string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, val) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSheetDataRange(t *testing.T) {
+	filename := writeBook(t, [][]interface{}{
+		nil,
+		{"Отдел", "Сумма"},
+		{"Склад", 10},
+		{"Офис", 20, "примечание"},
+		nil,
+		{"Склад", 30},
+	})
+	wb, err := OpenWorkbook(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+
+	dataRange, headers, count, err := wb.sheetDataRange("Лист1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dataRange != "Лист1!$A$2:$C$6" || count != 4 || !headers["Отдел"] || !headers["Сумма"] {
+		t.Errorf("диапазон %v, строк данных %d, заголовки %v", dataRange, count, headers)
+	}
+}
+
+func TestAddPivotTablesFile(t *testing.T) {
+	filename := writeBook(t, [][]interface{}{
+		{"Отдел", "Сумма"},
+		{"Склад", 10},
+		{"Офис", 20},
+		{"Склад", 30},
+	})
+	pivot := PivotSettings{
+		SourceSheet: "Лист1",
+		Sheet:       "Свод",
+		Rows:        []PivotField{{Data: "Отдел"}},
+		Data:        []PivotField{{Data: "Сумма", Subtotal: "sum"}},
+	}
+
+	bad := pivot
+	bad.Data = []PivotField{{Data: "Итого"}}
+	if err := AddPivotTablesFile(filename, []PivotSettings{bad}, SaveOptions{}); err == nil || !strings.Contains(err.Error(), "Итого") {
+		t.Errorf("поле не из заголовка: ошибка %v", err)
+	}
+
+	if err := AddPivotTablesFile(filename, []PivotSettings{pivot}, SaveOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	parts := make(map[string]string)
+	for _, zf := range zr.File {
+		if strings.HasPrefix(zf.Name, "xl/pivot") && strings.HasSuffix(zf.Name, ".xml") {
+			rc, err := zf.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, _ := io.ReadAll(rc)
+			rc.Close()
+			parts[zf.Name] = string(b)
+		}
+	}
+	cache := parts["xl/pivotCache/pivotCacheDefinition1.xml"]
+	if !strings.Contains(cache, `ref="A1:B4"`) || !strings.Contains(cache, `sheet="Лист1"`) {
+		t.Errorf("источник сводной таблицы: %v", cache)
+	}
+	if table := parts["xl/pivotTables/pivotTable1.xml"]; !strings.Contains(table, `<location ref="A3:B7"`) {
+		t.Errorf("размещение сводной таблицы: %v", table)
+	}
+}
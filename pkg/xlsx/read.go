@@ -2,96 +2,190 @@ package xlsx
 
 import (
 	"fmt"
-	"strconv"
+	"strings"
+)
 
-	"github.com/xuri/excelize/v2"
+// Что читать из ячейки (FieldExcel.Value, SetValueMode)
+const (
+	ValueRaw       = "raw"       // значение без форматирования: числа, серийные номера дат (по умолчанию)
+	ValueFormatted = "formatted" // текстовые поля - как их показывает Excel по формату ячейки: лицевые счета с ведущими нулями
+	ValueBoth      = "both"      // значение без форматирования, текст ячейки - в поле записи <name>_text
 )
 
+// TextSuffix окончание имени поля записи с текстом ячейки в режиме ValueBoth
+const TextSuffix = "_text"
+
+// IsValueMode допустимый режим чтения ячеек (пусто - по умолчанию)
+func IsValueMode(mode string) bool {
+	switch mode {
+	case "", ValueRaw, ValueFormatted, ValueBoth:
+		return true
+	}
+	return false
+}
+
+// SetValueMode задаёт, что читать из ячеек листа: raw (по умолчанию), formatted или both.
+// Режим поля (FieldExcel.Value) важнее режима листа, formatted у листа действует на текстовые поля.
+// Для CSV, JSON и xls текст ячейки совпадает со значением. Стили ячеек Excel читаются потоком по XML листа
+// (в памяти - стили текущей строки и форматы по номеру стиля), см. sheetCells.
+func (s *FieldsExcel) SetValueMode(mode string) {
+	s.valueMode = strings.ToLower(strings.TrimSpace(mode))
+}
+
+// fieldValueMode режим чтения ячеек поля
+func (s FieldsExcel) fieldValueMode(v FieldExcel) string {
+	switch {
+	case v.Value != "":
+		return v.Value
+	case s.valueMode != "":
+		return s.valueMode
+	}
+	return ValueRaw
+}
+
 // ExcelToData чтение Excel-файла
 func (s *FieldsExcel) ExcelToData(filename string, startData int) ([]map[string]interface{}, error) {
 
-	s.log.Debug("Читаем файл: ", filename)
 	fmt.Print("Открываем файл...\r")
 
-	f, err := excelize.OpenFile(filename)
+	rr, err := s.Rows(filename, startData)
 	if err != nil {
-		return nil, fmt.Errorf("OpenFile %v", err)
-	}
-	defer func() {
-		if err := f.Close(); err != nil {
-			s.log.Error("Close file")
-		}
-	}()
-	if s.sheetName == "" {
-		s.sheetName = f.GetSheetName(0) // получаем имя 1 листа
+		return nil, err
 	}
-	if startData == 0 {
-		startData = 2
+	data, _, err := s.readData(rr, false)
+	return data, err
+}
+
+// ExcelToDataValidate чтение Excel-файла с проверкой всех строк.
+// Строки с ошибками пропускаются, ошибки по каждой ячейке собираются в список.
+func (s *FieldsExcel) ExcelToDataValidate(filename string, startData int) ([]map[string]interface{}, CellErrors, error) {
+
+	rr, err := s.Rows(filename, startData)
+	if err != nil {
+		return nil, nil, err
 	}
-	s.log.Debugf("Лист: %v, Строка начала данных: %v", s.sheetName, startData)
+	return s.readData(rr, true)
+}
 
-	i := 0
-	data := make([]map[string]interface{}, 0)
+// SheetToData чтение листа открытой книги (см. ExcelToData)
+func (s *FieldsExcel) SheetToData(wb *Workbook, startData int) ([]map[string]interface{}, error) {
+	rr, err := s.SheetRows(wb, startData)
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := s.readData(rr, false)
+	return data, err
+}
 
-	rows, err := f.Rows(s.sheetName)
+// SheetToDataValidate чтение листа открытой книги с проверкой всех строк (см. ExcelToDataValidate)
+func (s *FieldsExcel) SheetToDataValidate(wb *Workbook, startData int) ([]map[string]interface{}, CellErrors, error) {
+	rr, err := s.SheetRows(wb, startData)
 	if err != nil {
-		return nil, fmt.Errorf("f.Rows %v", err)
+		return nil, nil, err
 	}
-	for rows.Next() {
+	return s.readData(rr, true)
+}
 
-		row, err := rows.Columns(excelize.Options{RawCellValue: true})
-		if err != nil {
-			return nil, fmt.Errorf("rows.Columns %v", err)
-		}
+// readData читаем все записи курсора, collect - собирать ошибки ячеек вместо прерывания чтения
+func (s *FieldsExcel) readData(rr *RowsReader, collect bool) ([]map[string]interface{}, CellErrors, error) {
+	defer rr.Close()
+	if collect {
+		rr.CollectErrors()
+	}
 
-		if len(row) == 0 { // пропускаем пустую строку
-			continue
+	data := make([]map[string]interface{}, 0)
+	for rr.Next() {
+		data = append(data, rr.Record())
+
+		if len(data)%1000 == 0 {
+			fmt.Printf("Идет чтение строк файла...%v\r", len(data))
 		}
+	}
+	if err := rr.Err(); err != nil {
+		return nil, nil, err
+	}
+	if err := rr.Close(); err != nil {
+		return nil, nil, err
+	}
 
-		i++
-		if i < startData { // пропускаем шапку таблицы
-			continue
+	s.log.Debugf("Прочитано: %v, ошибок: %v", len(data), len(rr.Errors()))
+
+	return data, rr.Errors(), nil
+}
+
+// rowToRecord преобразуем строку файла в запись
+// columns - соответствие ключа поля номеру колонки в файле, rowNum - номер строки в файле (для ошибок),
+// rules - правила проверки полей, styles - форматы ячеек (nil - текст ячейки совпадает со значением)
+func (s *FieldsExcel) rowToRecord(row []string, columns map[int]int, rowNum int, rules map[int]*fieldRule, styles cellStyles) (map[string]interface{}, CellErrors) {
+	var errs CellErrors
+	dt := make(map[string]interface{}, len(s.fields))
+	unique := make(map[int]string) // значения unique-полей: запоминаются, если строка без ошибок
+	for _, key := range s.sortedKeys() {
+		v := s.fields[key]
+		v.date1904 = s.date1904
+		col := columns[key]
+		cell := ""
+		if col <= len(row) {
+			cell = row[col-1]
 		}
-		var val interface{}
-		dt := make(map[string]interface{}, len(s.fields))
-		for key, v := range s.fields {
-			if key > len(row) {
+		mode := s.fieldValueMode(v)
+		text := cell // текст ячейки, как его показывает Excel
+		if mode != ValueRaw && styles != nil && cell != "" {
+			var err error
+			if text, err = styles.cellText(col, rowNum, cell); err != nil {
+				errs = append(errs, s.newCellError(rowNum, col, v, cell, err))
 				continue
 			}
-			val = row[key-1]
-
-			if v.Type == "date" {
-				resFloat, err := strconv.ParseFloat(val.(string), 64)
-				if err != nil {
-					return nil, err
-				}
-				res, err := excelize.ExcelDateToTime(resFloat, false)
-				if err != nil {
-					return nil, err
-				}
-				val = res.Format(v.ParseFormat)
-			}
-			if v.Type == "float64" {
-				res, err := strconv.ParseFloat(val.(string), 64)
-				if err != nil {
-					return nil, err
-				}
-				val = res
-			}
-
-			dt[s.fields[key].Name] = val
 		}
-		data = append(data, dt)
-
-		if i%1000 == 0 {
-			fmt.Printf("Идет чтение строк файла...%v\r", i)
+		if mode == ValueFormatted && v.IsText() { // типизированные поля читаются по значению
+			cell = text
+		}
+		norm := s.normalizeNumber(v, cell)
+		val, err := cellToValue(v, norm)
+		if err == nil && rules[key] != nil {
+			err = rules[key].check(norm, val)
+		}
+		if err != nil {
+			errs = append(errs, s.newCellError(rowNum, col, v, cell, err))
+			continue
+		}
+		if rules[key] != nil && v.Unique {
+			unique[key] = rules[key].text(norm, val)
+		}
+		if col > len(row) {
+			continue
+		}
+		dt[v.Name] = val
+		if mode == ValueBoth {
+			dt[v.Name+TextSuffix] = text
 		}
 	}
-	if err = rows.Close(); err != nil {
-		return nil, err
+	if len(errs) == 0 {
+		for key, text := range unique {
+			rules[key].remember(text, rowNum)
+		}
 	}
+	return dt, errs
+}
 
-	s.log.Debug("Прочитано: ", len(data))
-
-	return data, nil
+// cellToValue преобразуем значение ячейки к типу поля (Converter.Parse)
+// пустая ячейка нетекстового поля - nil
+func cellToValue(v FieldExcel, cell string) (interface{}, error) {
+	c, ok := converter(v.Type)
+	if !ok {
+		return nil, fmt.Errorf("неизвестный тип поля %q", v.Type)
+	}
+	if !c.Text && strings.TrimSpace(cell) == "" {
+		return nil, nil
+	}
+	val, err := c.Parse(v, cell)
+	if err != nil {
+		return nil, err
+	}
+	if c.Validate != nil {
+		if err := c.Validate(v, val); err != nil {
+			return nil, err
+		}
+	}
+	return val, nil
 }
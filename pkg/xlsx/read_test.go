@@ -0,0 +1,178 @@
+package xlsx
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// nopLogger логгер для тестов
+type nopLogger struct{}
+
+func (nopLogger) Debug(args ...interface{})                 {}
+func (nopLogger) Debugf(format string, args ...interface{}) {}
+func (nopLogger) Error(args ...interface{})                 {}
+func (nopLogger) Errorf(format string, args ...interface{}) {}
+
+// writeBook сохраняем книгу с одним листом "Лист1" во временный файл, rows - значения строк с A1
+func writeBook(t *testing.T, rows [][]interface{}) string {
+	t.Helper()
+	f := excelize.NewFile()
+	defer f.Close()
+	if err := f.SetSheetName("Sheet1", "Лист1"); err != nil {
+		t.Fatal(err)
+	}
+	for r, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, r+1)
+		if err := f.SetSheetRow("Лист1", cell, &row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	filename := filepath.Join(t.TempDir(), "book.xlsx")
+	if err := f.SaveAs(filename); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+// newTestFields описание колонок листа "Лист1"
+func newTestFields(fields map[int]FieldExcel) *FieldsExcel {
+	fe := NewFieldsExcel("Лист1", fields, nopLogger{})
+	return &fe
+}
+
+func TestReadInt64(t *testing.T) {
+	filename := writeBook(t, [][]interface{}{
+		{"Счёт"},
+		{123},
+		{"00456"},
+		{"1.5E+3"},
+		{"abc"},
+		{"12.5"},
+	})
+	fe := newTestFields(map[int]FieldExcel{1: {Name: "account", Type: "int64"}})
+
+	data, errs, err := fe.ExcelToDataValidate(filename, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int64{123, 456, 1500}
+	if len(data) != len(want) {
+		t.Fatalf("прочитано записей %d, ожидалось %d: %v", len(data), len(want), data)
+	}
+	for i, w := range want {
+		if got := data[i]["account"]; got != w {
+			t.Errorf("запись %d: account = %v (%T), ожидалось %v", i+1, got, got, w)
+		}
+	}
+
+	if len(errs) != 2 {
+		t.Fatalf("ошибок %d, ожидалось 2: %v", len(errs), errs)
+	}
+	for i, row := range []int{5, 6} {
+		if e := errs[i]; e.Row != row || e.Column != "A" || e.Type != "int64" {
+			t.Errorf("ошибка %d: строка %d, колонка %s, тип %s; ожидалось строка %d, колонка A, тип int64", i+1, e.Row, e.Column, e.Type, row)
+		}
+	}
+}
+
+func TestValueModes(t *testing.T) {
+	f := excelize.NewFile()
+	defer f.Close()
+	rows := [][]interface{}{
+		{"Счёт", "Дата", "Сумма"},
+		{123, 45000, 1234.5},
+		nil, // пустая строка: стили читаются потоком по номерам строк
+		{4567, 45001, 7},
+	}
+	for r, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, r+1)
+		if err := f.SetSheetRow("Sheet1", cell, &row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for col, code := range map[string]string{"A": "000000", "B": "dd.mm.yyyy", "C": "#,##0.00"} {
+		code := code
+		style, err := f.NewStyle(&excelize.Style{CustomNumFmt: &code})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = f.SetCellStyle("Sheet1", col+"2", col+"4", style); err != nil {
+			t.Fatal(err)
+		}
+	}
+	filename := filepath.Join(t.TempDir(), "styles.xlsx")
+	if err := f.SaveAs(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(mode string) []map[string]interface{} {
+		fe := NewFieldsExcel("Sheet1", map[int]FieldExcel{
+			1: {Name: "account"},
+			2: {Name: "paid", Type: "date"},
+			3: {Name: "sum"},
+		}, nopLogger{})
+		fe.SetValueMode(mode)
+		data, err := fe.ExcelToData(filename, 2)
+		if err != nil {
+			t.Fatalf("режим %v: %v", mode, err)
+		}
+		if len(data) != 2 {
+			t.Fatalf("режим %v: прочитано записей %d, ожидалось 2", mode, len(data))
+		}
+		return data
+	}
+	paid := time.Date(2023, time.March, 16, 0, 0, 0, 0, time.UTC)
+
+	raw := read(ValueRaw)
+	if raw[1]["account"] != "4567" || raw[1]["sum"] != "7" || raw[1]["paid"] != paid {
+		t.Errorf("raw: %v", raw[1])
+	}
+
+	formatted := read(ValueFormatted)
+	if formatted[1]["account"] != "004567" || formatted[0]["sum"] != "1\u00a0234,50" || formatted[1]["paid"] != paid {
+		t.Errorf("formatted: %v, %v", formatted[0], formatted[1])
+	}
+
+	both := read(ValueBoth)
+	want := map[string]interface{}{
+		"account": "4567", "account_text": "004567",
+		"paid": paid, "paid_text": "16.03.2023",
+		"sum": "7", "sum_text": "7,00",
+	}
+	for k, w := range want {
+		if got := both[1][k]; got != w {
+			t.Errorf("both: %v = %v, ожидалось %v", k, got, w)
+		}
+	}
+}
+
+func TestStartRowBlankLines(t *testing.T) {
+	filename := writeBook(t, [][]interface{}{
+		{"Отчёт за март"},
+		nil, // пустая строка над таблицей
+		{"Ф.И.О.", "Сумма"},
+		{"Иванов", 10},
+		nil,
+		{"Петров", 20},
+	})
+	for _, headers := range []bool{false, true} {
+		fe := newTestFields(map[int]FieldExcel{
+			1: {Name: "fio", Header: "Ф.И.О."},
+			2: {Name: "sum", Header: "Сумма", Type: "int64"},
+		})
+		if headers {
+			fe.UseHeaders(0)
+		}
+		// start_row - номер строки листа в обоих режимах
+		data, err := fe.ExcelToData(filename, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) != 2 || data[0]["fio"] != "Иванов" || data[1]["sum"] != int64(20) {
+			t.Errorf("match_headers=%v: %v", headers, data)
+		}
+	}
+}
@@ -0,0 +1,357 @@
+package xlsx
+
+import (
+	"fmt"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// RowsReader построчное чтение Excel-файла без накопления данных в памяти.
+//
+//	rr, err := fe.Rows(filename, startData)
+//	if err != nil { ... }
+//	defer rr.Close()
+//	for rr.Next() {
+//		rec := rr.Record()
+//	}
+//	if err := rr.Err(); err != nil { ... }
+type RowsReader struct {
+	fe        *FieldsExcel
+	src       rowSource
+	startData int
+
+	i          int         // кол-во прочитанных непустых строк
+	rowNum     int         // номер текущей строки в файле
+	columns    map[int]int // ключ поля -> номер колонки в файле
+	rules      map[int]*fieldRule
+	headerFail *HeaderError
+	record     map[string]interface{}
+	err        error
+
+	collect bool       // собирать ошибки ячеек и продолжать чтение
+	errors  CellErrors // собранные ошибки ячеек
+}
+
+// rowSource источник строк файла (лист Excel, CSV)
+type rowSource interface {
+	Next() bool
+	Columns() ([]string, error)
+	Close() error
+}
+
+// cellStyles источник с форматами ячеек (лист Excel)
+type cellStyles interface {
+	cellText(col, rowNum int, raw string) (string, error) // текст ячейки, как его показывает Excel
+	cellNumFmt(col, rowNum int) (string, error)           // код числового формата ячейки
+}
+
+// excelSource строки листа Excel-файла
+type excelSource struct {
+	f       *excelize.File
+	rows    *excelize.Rows
+	owned   bool // книга открыта для чтения и закрывается вместе с источником
+	sheet   string
+	formats *cellFormats // форматы ячеек по стилю (загружаются при первом обращении)
+	cells   *sheetCells  // стили и типы ячеек текущей строки (XML листа читается вторым потоком)
+}
+
+func (src *excelSource) Next() bool {
+	return src.rows.Next()
+}
+
+func (src *excelSource) Columns() ([]string, error) {
+	row, err := src.rows.Columns(excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, fmt.Errorf("rows.Columns %v", err)
+	}
+	return row, nil
+}
+
+// cellAttr стиль и тип ячейки текущей строки
+func (src *excelSource) cellAttr(col, rowNum int) (cellAttr, error) {
+	if src.cells == nil {
+		cells, err := openSheetCells(src.f, src.sheet)
+		if err != nil {
+			return cellAttr{}, err
+		}
+		src.cells = cells
+		src.formats = newCellFormats(src.f)
+	}
+	return src.cells.cell(col, rowNum)
+}
+
+func (src *excelSource) cellText(col, rowNum int, raw string) (string, error) {
+	attr, err := src.cellAttr(col, rowNum)
+	if err != nil {
+		return "", err
+	}
+	return src.formats.display(attr, raw), nil
+}
+
+func (src *excelSource) cellNumFmt(col, rowNum int) (string, error) {
+	attr, err := src.cellAttr(col, rowNum)
+	if err != nil {
+		return "", err
+	}
+	return src.formats.numFormat(attr.style).Code, nil
+}
+
+func (src *excelSource) Close() error {
+	err := src.rows.Close()
+	if src.cells != nil {
+		if errCells := src.cells.Close(); err == nil {
+			err = errCells
+		}
+	}
+	if !src.owned {
+		return err
+	}
+	if errClose := src.f.Close(); err == nil {
+		err = errClose
+	}
+	return err
+}
+
+// Rows открывает файл для построчного чтения данных
+func (s *FieldsExcel) Rows(filename string, startData int) (*RowsReader, error) {
+	s.log.Debug("Читаем файл: ", filename)
+
+	rules, err := s.newRules()
+	if err != nil {
+		return nil, err
+	}
+
+	var src rowSource
+	switch {
+	case s.isCSV(filename):
+		if src, err = s.openCSV(filename); err != nil {
+			return nil, err
+		}
+		if startData == 0 && s.csv.NoHeader {
+			startData = 1
+		}
+	case s.isJSON(filename):
+		if src, err = s.openJSON(filename); err != nil {
+			return nil, err
+		}
+		// в JSON нет шапки: поля сопоставляются по имени (FieldExcel.Name)
+		rr := s.newRowsReader(src, 1, rules)
+		rr.columns = s.defaultColumns()
+		return rr, nil
+	case s.fileFormat(filename) == FormatXLS:
+		if src, err = s.openXLS(filename); err != nil {
+			return nil, err
+		}
+	default:
+		f, err := excelize.OpenFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("OpenFile %v", err)
+		}
+		if src, err = s.openSheet(f, true); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return s.newRowsReader(src, startData, rules), nil
+}
+
+// SheetRows построчное чтение листа открытой книги (книга при Close не закрывается)
+func (s *FieldsExcel) SheetRows(wb *Workbook, startData int) (*RowsReader, error) {
+	s.log.Debug("Читаем книгу: ", wb.filename)
+
+	rules, err := s.newRules()
+	if err != nil {
+		return nil, err
+	}
+	src, err := s.openSheet(wb.f, false)
+	if err != nil {
+		return nil, err
+	}
+	return s.newRowsReader(src, startData, rules), nil
+}
+
+// newRowsReader курсор по строкам источника
+func (s *FieldsExcel) newRowsReader(src rowSource, startData int, rules map[int]*fieldRule) *RowsReader {
+	if startData == 0 {
+		startData = 2
+	}
+	s.log.Debugf("Лист: %v, Строка начала данных: %v", s.sheetName, startData)
+
+	rr := &RowsReader{fe: s, src: src, startData: startData, rules: rules}
+	if s.matchHeaders {
+		s.log.Debugf("Поиск колонок по заголовкам, строка заголовка: %v", s.headerRow)
+	} else {
+		rr.columns = s.defaultColumns()
+	}
+	return rr
+}
+
+// openSheet открываем лист книги для чтения, owned - закрыть книгу вместе с источником
+func (s *FieldsExcel) openSheet(f *excelize.File, owned bool) (*excelSource, error) {
+	if s.sheetName == "" {
+		s.sheetName = f.GetSheetName(0) // получаем имя 1 листа
+	}
+	rows, err := f.Rows(s.sheetName)
+	if err != nil {
+		return nil, fmt.Errorf("f.Rows %v", err)
+	}
+	s.date1904 = false
+	if props, err := f.GetWorkbookProps(); err == nil && props.Date1904 != nil {
+		s.date1904 = *props.Date1904
+	}
+	return &excelSource{f: f, rows: rows, owned: owned, sheet: s.sheetName}, nil
+}
+
+// EachRow построчное чтение файла с вызовом fn для каждой записи.
+// Чтение прекращается при первой ошибке fn.
+func (s *FieldsExcel) EachRow(filename string, startData int, fn func(record map[string]interface{}) error) error {
+	rr, err := s.Rows(filename, startData)
+	if err != nil {
+		return err
+	}
+	defer rr.Close()
+
+	for rr.Next() {
+		if err := fn(rr.Record()); err != nil {
+			return err
+		}
+	}
+	return rr.Err()
+}
+
+// Next переходит к следующей записи. Возвращает false по окончании данных или при ошибке (см. Err).
+func (rr *RowsReader) Next() bool {
+	if rr.err != nil || rr.src == nil {
+		return false
+	}
+	rr.record = nil
+	s := rr.fe
+	for rr.src.Next() {
+		rr.rowNum++
+
+		row, err := rr.src.Columns()
+		if err != nil {
+			rr.err = err
+			return false
+		}
+
+		if rr.columns == nil && s.headerRow > 0 &&
+			(rr.rowNum > s.headerRow || rr.rowNum == s.headerRow && blankRow(row)) { // заданная строка заголовка пуста
+			rr.err = emptyHeaderError(s.headerRow)
+			return false
+		}
+		if len(row) == 0 { // пропускаем пустую строку
+			continue
+		}
+
+		rr.i++
+		if rr.columns == nil { // ищем строку заголовка
+			if rr.rowNum < s.headerRow {
+				continue
+			}
+			cols, herr := s.matchHeaderRow(row, rr.rowNum)
+			if herr == nil {
+				s.log.Debugf("Строка заголовка: %v, колонки: %v", rr.rowNum, cols)
+				rr.columns = cols
+				continue
+			}
+			if s.headerRow > 0 {
+				rr.err = herr
+				return false
+			}
+			if rr.headerFail == nil || len(herr.Missing) < len(rr.headerFail.Missing) {
+				rr.headerFail = herr
+			}
+			if rr.i >= headerSearchLimit {
+				rr.err = rr.headerFail
+				return false
+			}
+			continue
+		}
+		if rr.rowNum < rr.startData { // пропускаем шапку таблицы: start_row - номер строки листа, пустые строки считаются
+			continue
+		}
+
+		styles, _ := rr.src.(cellStyles)
+		record, errs := s.rowToRecord(row, rr.columns, rr.rowNum, rr.rules, styles)
+		if len(errs) > 0 {
+			if rr.collect { // строку с ошибками пропускаем
+				rr.errors = append(rr.errors, errs...)
+				continue
+			}
+			rr.err = errs
+			return false
+		}
+		rr.record = record
+		return true
+	}
+
+	if rr.columns == nil { // строка заголовка так и не найдена
+		if s.headerRow > 0 && rr.headerFail == nil {
+			rr.err = emptyHeaderError(s.headerRow)
+			return false
+		}
+		if rr.headerFail == nil {
+			rr.headerFail = &HeaderError{}
+		}
+		rr.err = rr.headerFail
+	}
+	return false
+}
+
+// CollectErrors включает режим проверки: строки с ошибками пропускаются,
+// а ошибки ячеек собираются (см. Errors) вместо прерывания чтения.
+func (rr *RowsReader) CollectErrors() {
+	rr.collect = true
+}
+
+// Errors ошибки ячеек, собранные в режиме проверки
+func (rr *RowsReader) Errors() CellErrors {
+	return rr.errors
+}
+
+// Record текущая запись
+func (rr *RowsReader) Record() map[string]interface{} {
+	return rr.record
+}
+
+// RowNum номер текущей строки в файле
+func (rr *RowsReader) RowNum() int {
+	return rr.rowNum
+}
+
+// NumFmt код числового формата ячейки поля name в текущей строке: "dd.mm.yyyy", "000000", "General".
+// Пусто - формат недоступен (CSV, JSON, xls) или поля нет в файле.
+func (rr *RowsReader) NumFmt(name string) (string, error) {
+	styles, ok := rr.src.(cellStyles)
+	if !ok || rr.record == nil {
+		return "", nil
+	}
+	for key, v := range rr.fe.fields {
+		if v.Name == name {
+			if col, ok := rr.columns[key]; ok && col > 0 {
+				return styles.cellNumFmt(col, rr.rowNum)
+			}
+			return "", nil
+		}
+	}
+	return "", fmt.Errorf("поле %v не найдено", name)
+}
+
+// Err ошибка, прервавшая чтение
+func (rr *RowsReader) Err() error {
+	return rr.err
+}
+
+// Close закрывает файл
+func (rr *RowsReader) Close() error {
+	if rr.src == nil {
+		return nil
+	}
+	err := rr.src.Close()
+	if err != nil {
+		rr.fe.log.Error("Close file")
+	}
+	rr.src = nil
+	return err
+}
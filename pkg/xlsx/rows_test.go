@@ -0,0 +1,88 @@
+package xlsx
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRowsReader(t *testing.T) {
+	const n = 2000
+	rows := [][]interface{}{{"Ф.И.О.", "Сумма"}}
+	for i := 1; i <= n; i++ {
+		rows = append(rows, []interface{}{fmt.Sprintf("Клиент %d", i), i})
+	}
+	filename := writeBook(t, rows)
+	fe := newTestFields(map[int]FieldExcel{
+		1: {Name: "fio"},
+		2: {Name: "sum", Type: "int64"},
+	})
+
+	rr, err := fe.Rows(filename, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rr.Close()
+	count := 0
+	var total int64
+	for rr.Next() {
+		count++
+		rec := rr.Record()
+		if rr.RowNum() != count+1 {
+			t.Fatalf("запись %d: RowNum = %d, ожидалось %d", count, rr.RowNum(), count+1)
+		}
+		if want := fmt.Sprintf("Клиент %d", count); rec["fio"] != want {
+			t.Fatalf("запись %d: fio = %v, ожидалось %v", count, rec["fio"], want)
+		}
+		total += rec["sum"].(int64)
+	}
+	if err := rr.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if count != n || total != n*(n+1)/2 {
+		t.Errorf("прочитано записей %d (сумма %d), ожидалось %d (сумма %d)", count, total, n, n*(n+1)/2)
+	}
+	if err := rr.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if rr.Next() {
+		t.Error("Next после Close вернул true")
+	}
+}
+
+func TestEachRowStops(t *testing.T) {
+	filename := writeBook(t, [][]interface{}{{"Сумма"}, {1}, {2}, {3}})
+	fe := newTestFields(map[int]FieldExcel{1: {Name: "sum", Type: "int64"}})
+
+	stop := errors.New("стоп")
+	var got []interface{}
+	err := fe.EachRow(filename, 2, func(rec map[string]interface{}) error {
+		got = append(got, rec["sum"])
+		if len(got) == 2 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || len(got) != 2 {
+		t.Errorf("EachRow = %v, записи %v; ожидалась ошибка %v после 2 записей", err, got, stop)
+	}
+}
+
+func TestRowsReaderCellError(t *testing.T) {
+	filename := writeBook(t, [][]interface{}{{"Сумма"}, {1}, {"abc"}, {3}})
+	fe := newTestFields(map[int]FieldExcel{1: {Name: "sum", Type: "int64"}})
+
+	rr, err := fe.Rows(filename, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rr.Close()
+	count := 0
+	for rr.Next() {
+		count++
+	}
+	var errs CellErrors
+	if !errors.As(rr.Err(), &errs) || count != 1 || errs[0].Row != 3 {
+		t.Errorf("прочитано записей %d, ошибка %v; ожидалась 1 запись и ошибка в строке 3", count, rr.Err())
+	}
+}
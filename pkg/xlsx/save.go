@@ -0,0 +1,114 @@
+package xlsx
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// SaveOptions настройки сохранения файлов
+type SaveOptions struct {
+	Backup bool // сохранять предыдущую версию файла как <имя>.bak
+}
+
+// SetSaveOptions задаёт настройки сохранения файлов, записываемых по описанию полей
+func (s *FieldsExcel) SetSaveOptions(opts SaveOptions) {
+	s.save = opts
+}
+
+// saveWorkbook сохраняем книгу в файл filename через writeFileAtomic
+func saveWorkbook(f *excelize.File, filename string, opts SaveOptions) error {
+	f.Path = filename // по расширению excelize определяет тип содержимого (xlsx, xlsm, ...)
+	return writeFileAtomic(filename, opts, func(w io.Writer) error {
+		_, err := f.WriteTo(w)
+		return err
+	})
+}
+
+// writeFileAtomic записываем файл через временный файл в том же каталоге:
+// запись, fsync и переименование поверх filename. При сбое прежний файл остаётся нетронутым.
+func writeFileAtomic(filename string, opts SaveOptions, write func(w io.Writer) error) (err error) {
+	dir := filepath.Dir(filename)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("CreateTemp %v", err)
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err = write(tmp); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return fmt.Errorf("Sync %v", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	var mode os.FileMode = 0644
+	info, statErr := os.Stat(filename)
+	if statErr == nil {
+		mode = info.Mode().Perm()
+		if opts.Backup {
+			if err = backupFile(filename); err != nil {
+				return fmt.Errorf("резервная копия %v: %w", filename, err)
+			}
+		}
+	}
+	if err = os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), filename); err != nil {
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// backupFile сохраняем текущую версию файла в <имя>.bak
+func backupFile(filename string) error {
+	bak := filename + ".bak"
+	if err := os.Remove(bak); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Link(filename, bak); err == nil {
+		return nil
+	}
+	// жёсткие ссылки поддерживаются не везде - копируем
+	src, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.Create(bak)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err = dst.Sync(); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// syncDir фиксируем на диске переименование в каталоге (где это поддерживается)
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
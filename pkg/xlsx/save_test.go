@@ -0,0 +1,82 @@
+package xlsx
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestWriteFileAtomicFailure(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "data.csv")
+	if err := os.WriteFile(filename, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fail := errors.New("сбой записи")
+	err := writeFileAtomic(filename, SaveOptions{Backup: true}, func(w io.Writer) error {
+		io.WriteString(w, "new, но не полностью")
+		return fail
+	})
+	if err != fail {
+		t.Fatalf("writeFileAtomic = %v, ожидалось %v", err, fail)
+	}
+	if b, _ := os.ReadFile(filename); string(b) != "old" {
+		t.Errorf("после сбоя файл = %q, ожидалось %q", b, "old")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("после сбоя в каталоге остались файлы: %v", entries)
+	}
+}
+
+func TestWriteFileAtomicBackup(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(filename, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := writeFileAtomic(filename, SaveOptions{Backup: true}, func(w io.Writer) error {
+		_, err := io.WriteString(w, "new")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(filename); string(b) != "new" {
+		t.Errorf("файл = %q, ожидалось %q", b, "new")
+	}
+	if b, _ := os.ReadFile(filename + ".bak"); string(b) != "old" {
+		t.Errorf("резервная копия = %q, ожидалось %q", b, "old")
+	}
+	if info, err := os.Stat(filename); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("права файла %v (%v), ожидалось -rw-------", info.Mode().Perm(), err)
+	}
+}
+
+func TestDataToExcelBackup(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "out.xlsx")
+	fe := NewFieldsExcel("Вывод", map[int]FieldExcel{1: {Name: "fio", Header: "Ф.И.О."}}, nopLogger{})
+	fe.SetSaveOptions(SaveOptions{Backup: true})
+	for _, fio := range []string{"Иванов", "Петров"} {
+		if err := fe.DataToExcel(filename, 1, []map[string]interface{}{{"fio": fio}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, want := range map[string]string{filename: "Петров", filename + ".bak": "Иванов"} {
+		f, err := excelize.OpenFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := f.GetCellValue("Вывод", "A2")
+		f.Close()
+		if got != want {
+			t.Errorf("%v: A2 = %q, ожидалось %q", filepath.Base(name), got, want)
+		}
+	}
+}
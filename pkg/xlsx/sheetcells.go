@@ -0,0 +1,239 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// cellAttr атрибуты ячейки из XML листа
+type cellAttr struct {
+	style int    // номер стиля (s)
+	typ   string // тип значения (t): пусто или n - число, b, e, s, str, inlineStr, d
+}
+
+// sheetCells потоковое чтение атрибутов ячеек листа (стиль и тип) параллельно с excelize.Rows.
+// API excelize для отдельной ячейки (GetCellStyle, GetCellType) загружает в память весь лист,
+// здесь же XML листа читается вторым потоком, а в памяти - только атрибуты ячеек текущей строки.
+// Строки читаются по порядку, к уже прочитанным вернуться нельзя.
+type sheetCells struct {
+	rc    io.ReadCloser
+	dec   *xml.Decoder
+	row   int              // номер последней прочитанной строки
+	attrs map[int]cellAttr // колонка -> атрибуты ячеек строки row
+	eof   bool
+}
+
+// openSheetCells открываем XML листа sheet книги f для чтения атрибутов ячеек
+func openSheetCells(f *excelize.File, sheet string) (*sheetCells, error) {
+	name, err := sheetPartName(f, sheet)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := openPart(f, name)
+	if err != nil {
+		return nil, err
+	}
+	return &sheetCells{rc: rc, dec: xml.NewDecoder(rc), attrs: make(map[int]cellAttr)}, nil
+}
+
+// cell атрибуты ячейки col строки rowNum (нет ячейки в файле - стиль 0, число)
+func (sc *sheetCells) cell(col, rowNum int) (cellAttr, error) {
+	for !sc.eof && sc.row < rowNum {
+		if err := sc.nextRow(); err != nil {
+			return cellAttr{}, err
+		}
+	}
+	if sc.row != rowNum {
+		return cellAttr{}, nil
+	}
+	return sc.attrs[col], nil
+}
+
+// nextRow читаем атрибуты ячеек следующей строки листа
+func (sc *sheetCells) nextRow() error {
+	for {
+		tok, err := sc.dec.Token()
+		if err == io.EOF {
+			sc.eof = true
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("xml листа %v", err)
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok || se.Name.Local != "row" {
+			continue
+		}
+		row := sc.row + 1
+		for _, a := range se.Attr {
+			if a.Name.Local == "r" {
+				if n, err := strconv.Atoi(a.Value); err == nil {
+					row = n
+				}
+			}
+		}
+		sc.row = row
+		for k := range sc.attrs {
+			delete(sc.attrs, k)
+		}
+		return sc.readCells()
+	}
+}
+
+// readCells атрибуты ячеек до конца текущей строки
+func (sc *sheetCells) readCells() error {
+	col := 0
+	for {
+		tok, err := sc.dec.Token()
+		if err != nil {
+			return fmt.Errorf("xml листа %v", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "c" {
+				col++
+				var attr cellAttr
+				for _, a := range t.Attr {
+					switch a.Name.Local {
+					case "r":
+						if c, _, err := excelize.CellNameToCoordinates(a.Value); err == nil {
+							col = c
+						}
+					case "s":
+						attr.style, _ = strconv.Atoi(a.Value)
+					case "t":
+						attr.typ = a.Value
+					}
+				}
+				sc.attrs[col] = attr
+			}
+			if err := sc.dec.Skip(); err != nil { // значение и формула ячейки не нужны
+				return fmt.Errorf("xml листа %v", err)
+			}
+		case xml.EndElement:
+			if t.Name.Local == "row" {
+				return nil
+			}
+		}
+	}
+}
+
+func (sc *sheetCells) Close() error {
+	return sc.rc.Close()
+}
+
+// sheetPartName путь XML листа в пакете книги: xl/worksheets/sheet1.xml
+func sheetPartName(f *excelize.File, sheet string) (string, error) {
+	wbName := "xl/workbook.xml"
+	var rels struct {
+		Items []struct {
+			ID     string `xml:"Id,attr"`
+			Type   string `xml:"Type,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := readPartXML(f, "_rels/.rels", &rels); err == nil {
+		for _, r := range rels.Items {
+			if strings.HasSuffix(r.Type, "/officeDocument") {
+				wbName = strings.TrimPrefix(r.Target, "/")
+			}
+		}
+	}
+
+	var wb struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			ID   string `xml:"id,attr"` // r:id
+		} `xml:"sheets>sheet"`
+	}
+	if err := readPartXML(f, wbName, &wb); err != nil {
+		return "", err
+	}
+	id := ""
+	for _, s := range wb.Sheets {
+		if strings.EqualFold(s.Name, sheet) {
+			id = s.ID
+		}
+	}
+	if id == "" {
+		return "", fmt.Errorf("лист %v не найден в файле книги", sheet)
+	}
+
+	dir, file := path.Split(wbName)
+	rels.Items = nil
+	if err := readPartXML(f, dir+"_rels/"+file+".rels", &rels); err != nil {
+		return "", err
+	}
+	for _, r := range rels.Items {
+		if r.ID == id {
+			if strings.HasPrefix(r.Target, "/") {
+				return r.Target[1:], nil
+			}
+			return path.Join(dir, r.Target), nil
+		}
+	}
+	return "", fmt.Errorf("лист %v не найден в файле книги", sheet)
+}
+
+// readPartXML разбор XML части книги
+func readPartXML(f *excelize.File, name string, v interface{}) error {
+	rc, err := openPart(f, name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("xml %v: %v", name, err)
+	}
+	return nil
+}
+
+// openPart поток части книги: из памяти excelize или, для больших листов, из файла книги
+func openPart(f *excelize.File, name string) (io.ReadCloser, error) {
+	if content, ok := f.Pkg.Load(name); ok {
+		if b, ok := content.([]byte); ok && len(b) > 0 {
+			return io.NopCloser(bytes.NewReader(b)), nil
+		}
+	}
+	if f.Path == "" {
+		return nil, fmt.Errorf("часть книги %v не найдена", name)
+	}
+	zr, err := zip.OpenReader(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("zip.OpenReader %v", err)
+	}
+	for _, zf := range zr.File {
+		if zf.Name == name {
+			rc, err := zf.Open()
+			if err != nil {
+				zr.Close()
+				return nil, fmt.Errorf("zip %v: %v", name, err)
+			}
+			return &zipPart{ReadCloser: rc, zr: zr}, nil
+		}
+	}
+	zr.Close()
+	return nil, fmt.Errorf("часть книги %v не найдена", name)
+}
+
+// zipPart поток части книги, при закрытии закрывается и архив
+type zipPart struct {
+	io.ReadCloser
+	zr *zip.ReadCloser
+}
+
+func (p *zipPart) Close() error {
+	err := p.ReadCloser.Close()
+	if errZip := p.zr.Close(); err == nil {
+		err = errZip
+	}
+	return err
+}
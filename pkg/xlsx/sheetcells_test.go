@@ -0,0 +1,62 @@
+package xlsx
+
+import (
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestSheetCellsFromFile(t *testing.T) {
+	filename := writeBook(t, [][]interface{}{
+		{"Текст", 1},
+		nil,
+		{true, 2.5},
+	})
+	f, err := excelize.OpenFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	style, err := f.NewStyle(&excelize.Style{NumFmt: 14})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = f.SetCellStyle("Лист1", "B3", "B3", style); err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Save(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	// лист больше UnzipXMLSizeLimit хранится не в памяти excelize - атрибуты читаются из файла книги
+	f, err = excelize.OpenFile(filename, excelize.Options{UnzipXMLSizeLimit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	cells, err := openSheetCells(f, "Лист1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cells.Close()
+
+	tests := []struct {
+		col, row int
+		want     cellAttr
+	}{
+		{1, 1, cellAttr{typ: "s"}},
+		{2, 1, cellAttr{}},
+		{1, 2, cellAttr{}}, // строки нет в файле
+		{1, 3, cellAttr{typ: "b"}},
+		{2, 3, cellAttr{style: style}},
+	}
+	for _, tt := range tests {
+		got, err := cells.cell(tt.col, tt.row)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("ячейка %d:%d = %+v, ожидалось %+v", tt.col, tt.row, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package xlsx
+
+import "io"
+
+// NextRecord возвращает очередную запись для вывода, io.EOF - записей больше нет
+type NextRecord func() (map[string]interface{}, error)
+
+// StreamToExcel Записываем в файл записи, получаемые по одной из next (без накопления данных в памяти)
+func (s *FieldsExcel) StreamToExcel(filename string, startRow int, next NextRecord) error {
+	_, err := s.dataToExcel(filename, startRow, next, false)
+	return err
+}
+
+// StreamToSheet Записываем на лист открытой книги записи, получаемые по одной из next
+func (s *FieldsExcel) StreamToSheet(wb *Workbook, startRow int, next NextRecord) error {
+	_, err := s.dataToSheet(wb, startRow, next, false)
+	return err
+}
+
+// sliceRecords записи среза по одной
+func sliceRecords(data []map[string]interface{}) NextRecord {
+	i := 0
+	return func() (map[string]interface{}, error) {
+		if i >= len(data) {
+			return nil, io.EOF
+		}
+		i++
+		return data[i-1], nil
+	}
+}
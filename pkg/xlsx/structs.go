@@ -0,0 +1,409 @@
+package xlsx
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+	decimalType       = reflect.TypeOf(Decimal{})
+	textUnmarshalType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	textMarshalType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// modelField описание поля структуры для чтения/записи
+type modelField struct {
+	index int        // номер поля в структуре
+	field FieldExcel // описание колонки (Name - тег db или имя поля)
+}
+
+// isTimeType поле типа time.Time или *time.Time
+func isTimeType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == timeType
+}
+
+// modelFields получаем описание полей структуры из тегов,
+// номер колонки - порядковый номер среди экспортируемых полей без db:"-"
+func modelFields(t reflect.Type) ([]modelField, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("модель должна быть структурой: %v", t)
+	}
+	res := make([]modelField, 0, t.NumField())
+	fields := make(map[int]FieldExcel, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		curField := t.Field(i)
+		if curField.PkgPath != "" || curField.Tag.Get("db") == "-" { // неэкспортируемые и исключённые поля
+			continue
+		}
+		v, err := modelFieldExcel(curField)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, modelField{index: i})
+		fields[len(res)] = v
+	}
+	FieldsDefaults(fields)
+	for k := range res {
+		res[k].field = fields[k+1]
+	}
+	return res, nil
+}
+
+// modelFieldExcel описание колонки по тегам поля: db, header, width, format, type, time_zone.
+// Если тег type не задан, тип колонки определяется по типу поля.
+func modelFieldExcel(curField reflect.StructField) (FieldExcel, error) {
+	width := 10.0
+	if tmp := strings.TrimSpace(curField.Tag.Get("width")); tmp != "" {
+		var err error
+		if width, err = strconv.ParseFloat(tmp, 64); err != nil {
+			return FieldExcel{}, fmt.Errorf("width=<%v> name=<%v> : %w", tmp, curField.Name, err)
+		}
+	}
+
+	name := curField.Tag.Get("db")
+	if name == "" {
+		name = curField.Name
+	}
+	format := curField.Tag.Get("format")
+	if format == "" && isTimeType(curField.Type) { // даты без формата выводим как дд.мм.гггг
+		format = "dd.mm.yyyy"
+	}
+	typ := curField.Tag.Get("type")
+	if typ == "" {
+		typ = modelFieldType(curField.Type, format)
+	}
+
+	return FieldExcel{
+		Name:     name,
+		Header:   curField.Tag.Get("header"),
+		Width:    width,
+		Format:   format,
+		Type:     typ,
+		TimeZone: curField.Tag.Get("time_zone"),
+	}, nil
+}
+
+// modelFieldType тип колонки по типу поля структуры, пусто - текст
+func modelFieldType(t reflect.Type, format string) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return timeFieldType(format)
+	case durationType:
+		return "duration"
+	case decimalType:
+		return "decimal"
+	}
+	if reflect.PtrTo(t).Implements(textUnmarshalType) { // свои типы разбираются из текста ячейки
+		return ""
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int64"
+	case reflect.Float32, reflect.Float64:
+		return "float64"
+	case reflect.Bool:
+		return "bool"
+	}
+	return ""
+}
+
+// timeFieldType тип колонки даты по формату Excel: date, datetime или time
+func timeFieldType(format string) string {
+	f := strings.ToLower(format)
+	hasTime := strings.ContainsAny(f, "hs")
+	switch {
+	case hasTime && strings.ContainsAny(f, "dy"):
+		return "datetime"
+	case hasTime:
+		return "time"
+	}
+	return "date"
+}
+
+// ExcelToStructs чтение Excel-файла в срез структур T.
+// Колонки описываются тегами модели (db, header, width, format), номер колонки - порядковый номер среди экспортируемых полей без db:"-".
+func ExcelToStructs[T any](filename, sheetName string, startData int, log Logger) ([]T, error) {
+	var model T
+	fe, err := NewFromModelTags(model, log)
+	if err != nil {
+		return nil, err
+	}
+	fe.sheetName = sheetName
+	data, err := fe.ExcelToData(filename, startData)
+	if err != nil {
+		return nil, err
+	}
+	return RecordsToStructs[T](data)
+}
+
+// StructsToExcel запись среза структур T в Excel-файл.
+// Колонки описываются тегами модели (db, header, width, format), номер колонки - порядковый номер среди экспортируемых полей без db:"-".
+func StructsToExcel[T any](filename, sheetName string, startRow int, data []T, log Logger) error {
+	var model T
+	fe, err := NewFromModelTags(model, log)
+	if err != nil {
+		return err
+	}
+	fe.sheetName = sheetName
+	records, err := StructsToRecords(data)
+	if err != nil {
+		return err
+	}
+	return fe.DataToExcel(filename, startRow, records)
+}
+
+// RecordsToStructs преобразуем записи (результат ExcelToData) в срез структур T.
+// Значения берутся по тегу db (или имени поля).
+func RecordsToStructs[T any](data []map[string]interface{}) ([]T, error) {
+	fields, err := modelFields(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+	res := make([]T, len(data))
+	for r, row := range data {
+		rv := reflect.ValueOf(&res[r]).Elem()
+		for _, mf := range fields {
+			val, ok := row[mf.field.Name]
+			if !ok {
+				continue
+			}
+			if err := setFieldValue(rv.Field(mf.index), val, mf.field); err != nil {
+				return nil, fmt.Errorf("запись %d, поле %v: %w", r+1, mf.field.Name, err)
+			}
+		}
+	}
+	return res, nil
+}
+
+// StructsToRecords преобразуем срез структур T в записи для DataToExcel.
+// Ключи записи - тег db (или имя поля), пустые указатели - nil.
+func StructsToRecords[T any](data []T) ([]map[string]interface{}, error) {
+	fields, err := modelFields(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+	res := make([]map[string]interface{}, len(data))
+	for r := range data {
+		rv := reflect.ValueOf(&data[r]).Elem()
+		dt := make(map[string]interface{}, len(fields))
+		for _, mf := range fields {
+			val, err := fieldValue(rv.Field(mf.index))
+			if err != nil {
+				return nil, fmt.Errorf("запись %d, поле %v: %w", r+1, mf.field.Name, err)
+			}
+			dt[mf.field.Name] = val
+		}
+		res[r] = dt
+	}
+	return res, nil
+}
+
+// setFieldValue записываем значение ячейки в поле структуры с приведением типа,
+// v - описание колонки поля (формат и часовой пояс дат, слова для bool)
+func setFieldValue(fv reflect.Value, val interface{}, v FieldExcel) error {
+	if val == nil {
+		return nil
+	}
+	if str, ok := val.(string); ok && strings.TrimSpace(str) == "" {
+		return nil // пустая ячейка - нулевое значение (nil для указателя)
+	}
+
+	if fv.Kind() == reflect.Ptr {
+		ptr := reflect.New(fv.Type().Elem())
+		if err := setFieldValue(ptr.Elem(), val, v); err != nil {
+			return err
+		}
+		fv.Set(ptr)
+		return nil
+	}
+
+	layout := v.ParseFormat
+	if layout == "" {
+		layout = "02.01.2006"
+	}
+	if fv.Type() == timeType {
+		t, err := toTime(val, v)
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	if reflect.PtrTo(fv.Type()).Implements(textUnmarshalType) {
+		return fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(toString(val, layout)))
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(toString(val, layout))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := toInt64(val)
+		if err != nil {
+			return err
+		}
+		if fv.OverflowInt(n) {
+			return fmt.Errorf("значение %v вне диапазона %v", n, fv.Type())
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := toInt64(val)
+		if err != nil {
+			return err
+		}
+		if n < 0 || fv.OverflowUint(uint64(n)) {
+			return fmt.Errorf("значение %v вне диапазона %v", n, fv.Type())
+		}
+		fv.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		n, err := toFloat64(val)
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(n)
+	case reflect.Bool:
+		b, ok := val.(bool)
+		if !ok {
+			var err error
+			if b, err = v.parseBool(toString(val, layout)); err != nil {
+				return err
+			}
+		}
+		fv.SetBool(b)
+	default:
+		return fmt.Errorf("тип поля %v не поддерживается", fv.Type())
+	}
+	return nil
+}
+
+// fieldValue получаем значение поля структуры для записи в ячейку
+func fieldValue(fv reflect.Value) (interface{}, error) {
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			return nil, nil
+		}
+		fv = fv.Elem()
+	}
+	switch fv.Type() {
+	case timeType, durationType, decimalType:
+		return fv.Interface(), nil
+	}
+	if fv.Type().Implements(textMarshalType) {
+		text, err := fv.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return string(text), nil
+	}
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if fv.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("значение %v больше максимального int64", fv.Uint())
+		}
+		return int64(fv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return fv.Float(), nil
+	case reflect.String:
+		return fv.String(), nil
+	case reflect.Bool:
+		return fv.Bool(), nil
+	}
+	return nil, fmt.Errorf("тип поля %v не поддерживается", fv.Type())
+}
+
+// toString значение ячейки в виде строки (числа без потери точности)
+func toString(val interface{}, layout string) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case time.Time:
+		return v.Format(layout)
+	}
+	return fmt.Sprint(val)
+}
+
+// toInt64 значение ячейки в виде целого числа
+func toInt64(val interface{}) (int64, error) {
+	switch v := val.(type) {
+	case int64:
+		return v, nil
+	case time.Duration:
+		return int64(v), nil
+	case Decimal:
+		if v.scale != 0 {
+			return 0, fmt.Errorf("значение %v не целое", v)
+		}
+		return v.coef, nil
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("значение %v не целое", v)
+		}
+		return int64(v), nil
+	case string:
+		v = strings.TrimSpace(v)
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n, nil
+		}
+		f, err := strconv.ParseFloat(v, 64) // Excel может хранить целые как 1.5E+5
+		if err != nil {
+			return 0, err
+		}
+		return toInt64(f)
+	}
+	return 0, fmt.Errorf("значение %v (%T) не число", val, val)
+}
+
+// toFloat64 значение ячейки в виде дробного числа
+func toFloat64(val interface{}) (float64, error) {
+	switch v := val.(type) {
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case Decimal:
+		return v.Float64(), nil
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(v), 64)
+	}
+	return 0, fmt.Errorf("значение %v (%T) не число", val, val)
+}
+
+// toTime значение ячейки в виде даты: серийный номер Excel (система дат 1900) или текст в формате поля,
+// часовой пояс - time_zone поля
+func toTime(val interface{}, v FieldExcel) (time.Time, error) {
+	switch x := val.(type) {
+	case time.Time:
+		return x, nil
+	case float64:
+		return v.ExcelTime(x)
+	case int64:
+		return v.ExcelTime(float64(x))
+	case string:
+		t, err := parseDateCell(v, x)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return t.(time.Time), nil
+	}
+	return time.Time{}, fmt.Errorf("значение %v (%T) не дата", val, val)
+}
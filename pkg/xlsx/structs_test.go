@@ -0,0 +1,110 @@
+package xlsx
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // часовые пояса для time_zone без системной базы
+
+	"github.com/xuri/excelize/v2"
+)
+
+type testPayment struct {
+	Account int64      `db:"account" header:"Счёт"`
+	Paid    time.Time  `db:"paid" format:"dd.mm.yyyy"`
+	At      *time.Time `db:"at" format:"dd.mm.yyyy hh:mm" time_zone:"Europe/Moscow"`
+	Sum     Decimal    `db:"sum"`
+	Done    bool       `db:"done"`
+	Note    string     `db:"-"`
+	Fio     string     `db:"fio"`
+}
+
+func TestExcelToStructs1904(t *testing.T) {
+	filename := writeBook(t, [][]interface{}{
+		{"Счёт", "Оплачено", "Время", "Сумма", "Готово", "Ф.И.О."},
+		{1001, 45000, 45000.5, "12.50", "да", "Иванов"},
+		{1002, 45001, nil, 7, "нет", "Петров"},
+	})
+	f, err := excelize.OpenFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	date1904 := true
+	if err = f.SetWorkbookProps(&excelize.WorkbookPropsOptions{Date1904: &date1904}); err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Save(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	got, err := ExcelToStructs[testPayment](filename, "Лист1", 2, nopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("прочитано записей %d, ожидалось 2", len(got))
+	}
+
+	// серийный номер 45000 в системе 1904 - 16.03.2027 (в системе 1900 - 15.03.2023)
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2027, time.March, 16, 12, 0, 0, 0, moscow)
+	p := got[0]
+	if p.Account != 1001 || p.Fio != "Иванов" || !p.Done || p.Note != "" {
+		t.Errorf("запись 1 = %+v", p)
+	}
+	if want := time.Date(2027, time.March, 16, 0, 0, 0, 0, time.UTC); !p.Paid.Equal(want) {
+		t.Errorf("Paid = %v, ожидалось %v", p.Paid, want)
+	}
+	if p.At == nil || !p.At.Equal(at) || p.At.Location().String() != "Europe/Moscow" {
+		t.Errorf("At = %v, ожидалось %v", p.At, at)
+	}
+	if p.Sum.String() != "12.5" {
+		t.Errorf("Sum = %v, ожидалось 12.5", p.Sum)
+	}
+
+	p = got[1]
+	if p.Done || p.At != nil || p.Sum.String() != "7" {
+		t.Errorf("запись 2 = %+v", p)
+	}
+}
+
+func TestRecordsToStructsBool(t *testing.T) {
+	type flags struct {
+		A bool `db:"a"`
+		B bool `db:"b"`
+		C bool `db:"c"`
+	}
+	got, err := RecordsToStructs[flags]([]map[string]interface{}{{"a": "да", "b": "Нет", "c": true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (flags{A: true, B: false, C: true}); got[0] != want {
+		t.Errorf("RecordsToStructs = %+v, ожидалось %+v", got[0], want)
+	}
+
+	if _, err = RecordsToStructs[flags]([]map[string]interface{}{{"a": "может быть"}}); err == nil {
+		t.Error("ожидалась ошибка для значения \"может быть\"")
+	}
+}
+
+func TestModelFieldTypes(t *testing.T) {
+	fe, err := NewFromModelTags(testPayment{}, nopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]string{1: "int64", 2: "date", 3: "datetime", 4: "decimal", 5: "bool", 6: ""}
+	if len(fe.fields) != len(want) {
+		t.Fatalf("колонок %d, ожидалось %d", len(fe.fields), len(want))
+	}
+	for col, typ := range want {
+		if got := fe.fields[col].Type; got != typ {
+			t.Errorf("колонка %d (%v): тип %q, ожидалось %q", col, fe.fields[col].Name, got, typ)
+		}
+	}
+	if got := fe.fields[3].ParseFormat; got != "02.01.2006 15:04" {
+		t.Errorf("формат разбора колонки 3 = %q, ожидалось %q", got, "02.01.2006 15:04")
+	}
+}
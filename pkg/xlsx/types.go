@@ -0,0 +1,179 @@
+package xlsx
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// слова для логических значений по умолчанию (регистр не учитывается)
+var (
+	defaultTrue  = []string{"true", "1", "да", "д", "yes", "y", "истина", "+"}
+	defaultFalse = []string{"false", "0", "нет", "н", "no", "n", "ложь", "-"}
+)
+
+// isDateType тип поля содержит дату и/или время (у типа задан формат разбора по умолчанию)
+func isDateType(typ string) bool {
+	c, ok := converter(typ)
+	return ok && c.Layout != ""
+}
+
+// isNumberType числовой тип поля
+func isNumberType(typ string) bool {
+	c, ok := converter(typ)
+	return ok && c.Number
+}
+
+// IsText поле без преобразования пустых значений: пустая ячейка - пустая строка, а не nil
+func (v FieldExcel) IsText() bool {
+	c, ok := converter(v.Type)
+	return ok && c.Text
+}
+
+// parseBool логическое значение по словам поля (true/false) или словам по умолчанию.
+// 1/0 и true/false (логические ячейки Excel, JSON) принимаются всегда.
+func (v FieldExcel) parseBool(str string) (bool, error) {
+	str = strings.TrimSpace(str)
+	if b, err := strconv.ParseBool(str); err == nil && (str == "1" || str == "0" || len(str) > 1) {
+		return b, nil
+	}
+	yes, no := v.True, v.False
+	if len(yes) == 0 && len(no) == 0 {
+		yes, no = defaultTrue, defaultFalse
+	}
+	for _, w := range yes {
+		if strings.EqualFold(strings.TrimSpace(w), str) {
+			return true, nil
+		}
+	}
+	for _, w := range no {
+		if strings.EqualFold(strings.TrimSpace(w), str) {
+			return false, nil
+		}
+	}
+	return false, fmt.Errorf("значение %q не является логическим", str)
+}
+
+// boolText логическое значение текстом: первое слово поля (true/false) или true/false
+func (v FieldExcel) boolText(b bool) string {
+	switch {
+	case b && len(v.True) > 0:
+		return v.True[0]
+	case !b && len(v.False) > 0:
+		return v.False[0]
+	}
+	return strconv.FormatBool(b)
+}
+
+// parseAmount денежная сумма из текста: "1 234,56 ₽", "$1,234.56", "(100.00)", "-15 руб."
+// Символы валюты и пробелы отбрасываются, десятичный разделитель - последняя точка или запятая.
+// Точка или запятая учитывается только перед цифрой (точка сокращения "руб." - не разделитель).
+func parseAmount(str string) (Decimal, error) {
+	var sb strings.Builder
+	neg := false
+	runes := []rune(strings.TrimSpace(str))
+	isDigit := func(i int) bool {
+		return i >= 0 && i < len(runes) && runes[i] >= '0' && runes[i] <= '9'
+	}
+	for i, r := range runes {
+		switch {
+		case isDigit(i):
+			sb.WriteRune(r)
+		case r == '.', r == ',':
+			if isDigit(i+1) && (isDigit(i-1) || sb.Len() == 0) {
+				sb.WriteRune(r)
+			}
+		case r == '-', r == '(', r == '−':
+			neg = true
+		}
+	}
+	num := sb.String()
+	if num == "" {
+		return Decimal{}, fmt.Errorf("значение %q не является суммой", str)
+	}
+	dots, commas := strings.Count(num, "."), strings.Count(num, ",")
+	switch {
+	case dots > 0 && commas > 0, dots+commas == 1: // десятичный разделитель - последний
+		sep := strings.LastIndexAny(num, ".,")
+		num = strings.NewReplacer(".", "", ",", "").Replace(num[:sep]) + "." + num[sep+1:]
+	default: // только разделители тысяч: 1,234,567
+		num = strings.NewReplacer(".", "", ",", "").Replace(num)
+	}
+	d, err := ParseDecimal(num)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("значение %q не является суммой", str)
+	}
+	if neg {
+		d.coef = -d.coef
+	}
+	return d, nil
+}
+
+// parsePercent доля из текста: "15%" и "15,5 %" - в процентах, число без знака % - доля (0.15), как хранит Excel
+func parsePercent(str string) (float64, error) {
+	str = strings.TrimSpace(str)
+	num := strings.TrimSpace(strings.TrimSuffix(str, "%"))
+	d, err := ParseDecimal(strings.NewReplacer(" ", "", "\u00a0", "").Replace(num))
+	if err != nil {
+		return 0, fmt.Errorf("значение %q не является процентом", str)
+	}
+	if num != str {
+		d.scale += 2
+	}
+	return d.Float64(), nil
+}
+
+// parseDuration длительность: часы "25:30", "25:30:15", "1:02:03.5", Go "1h30m" или число дней (как хранит Excel)
+func parseDuration(str string) (time.Duration, error) {
+	str = strings.TrimSpace(str)
+	if days, err := strconv.ParseFloat(str, 64); err == nil {
+		return daysToDuration(days), nil
+	}
+	if d, err := time.ParseDuration(str); err == nil {
+		return d, nil
+	}
+	neg := strings.HasPrefix(str, "-")
+	parts := strings.Split(strings.TrimPrefix(str, "-"), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("значение %q не является длительностью (ч:мм:сс)", str)
+	}
+	var d time.Duration
+	units := []time.Duration{time.Hour, time.Minute, time.Second}
+	for i, p := range parts {
+		n, err := strconv.ParseFloat(strings.Replace(p, ",", ".", 1), 64)
+		if err != nil || n < 0 || (i > 0 && n >= 60) || (i < len(parts)-1 && n != math.Trunc(n)) {
+			return 0, fmt.Errorf("значение %q не является длительностью (ч:мм:сс)", str)
+		}
+		d += time.Duration(n * float64(units[i]))
+	}
+	if neg {
+		d = -d
+	}
+	return d.Round(time.Millisecond), nil
+}
+
+// FormatDuration длительность текстом ч:мм:сс (часы не ограничены сутками), вид для CSV, JSON и базы данных
+func FormatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	h, m, s := d/time.Hour, d%time.Hour/time.Minute, d%time.Minute
+	text := fmt.Sprintf("%s%d:%02d:%02d", sign, h, m, s/time.Second)
+	if ms := s % time.Second / time.Millisecond; ms > 0 {
+		text += strings.TrimRight(fmt.Sprintf(".%03d", ms), "0")
+	}
+	return text
+}
+
+// daysToDuration дни (число Excel) в длительность
+func daysToDuration(days float64) time.Duration {
+	return time.Duration(days * float64(24*time.Hour)).Round(time.Millisecond)
+}
+
+// durationToDays длительность в дни (число Excel)
+func durationToDays(d time.Duration) float64 {
+	return d.Seconds() / 86400
+}
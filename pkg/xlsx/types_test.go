@@ -0,0 +1,40 @@
+package xlsx
+
+import "testing"
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"1 234,56 ₽", "1234.56"},
+		{"$1,234.56", "1234.56"},
+		{"(100.00)", "-100"},
+		{"-15 руб.", "-15"},
+		{"1 234,56 руб.", "1234.56"},
+		{"12.50 р.", "12.5"},
+		{"руб. 12,5", "12.5"},
+		{"1 234 567,89", "1234567.89"},
+		{"1,234,567", "1234567"},
+		{"1.234.567,8", "1234567.8"},
+		{"€ .5", "0.5"},
+		{"−7,00", "-7"},
+		{"798.63", "798.63"},
+	}
+	for _, tt := range tests {
+		got, err := parseAmount(tt.in)
+		if err != nil {
+			t.Errorf("parseAmount(%q): %v", tt.in, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("parseAmount(%q) = %s, ожидалось %s", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "руб.", "-", "abc"} {
+		if got, err := parseAmount(in); err == nil {
+			t.Errorf("parseAmount(%q) = %s, ожидалась ошибка", in, got)
+		}
+	}
+}
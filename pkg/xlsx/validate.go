@@ -0,0 +1,211 @@
+package xlsx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Limit граница допустимого значения поля (min/max): число или дата.
+// В конфиге задаётся числом или строкой, даты - в формате поля или yyyy-mm-dd.
+type Limit string
+
+// UnmarshalJSON принимаем как число, так и строку
+func (l *Limit) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '"' {
+		var str string
+		if err := json.Unmarshal(b, &str); err != nil {
+			return err
+		}
+		*l = Limit(str)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return fmt.Errorf("граница должна быть числом или строкой: %s", b)
+	}
+	*l = Limit(n)
+	return nil
+}
+
+// fieldRule подготовленные правила проверки значения поля
+type fieldRule struct {
+	field            FieldExcel
+	re               *regexp.Regexp
+	min, max         *float64
+	minDate, maxDate *time.Time
+	enum             map[string]bool
+	seen             map[string]int // для unique: значение -> номер строки
+}
+
+// hasRules заданы ли для поля правила проверки
+func (v FieldExcel) hasRules() bool {
+	return v.Required || v.Min != "" || v.Max != "" || v.Regex != "" ||
+		v.MaxLength > 0 || len(v.Enum) > 0 || v.Unique
+}
+
+// newRules подготавливаем правила проверки полей (ключ - ключ поля)
+func (s FieldsExcel) newRules() (map[int]*fieldRule, error) {
+	rules := make(map[int]*fieldRule)
+	for key, v := range s.fields {
+		if !v.hasRules() {
+			continue
+		}
+		r := &fieldRule{field: v}
+		var err error
+		if v.Regex != "" {
+			if r.re, err = regexp.Compile(v.Regex); err != nil {
+				return nil, fmt.Errorf("поле %v: regex %q: %w", v.Name, v.Regex, err)
+			}
+		}
+		if isDateType(v.Type) {
+			if r.minDate, err = v.parseDateLimit(v.Min); err != nil {
+				return nil, fmt.Errorf("поле %v: min %q: %w", v.Name, v.Min, err)
+			}
+			if r.maxDate, err = v.parseDateLimit(v.Max); err != nil {
+				return nil, fmt.Errorf("поле %v: max %q: %w", v.Name, v.Max, err)
+			}
+		} else {
+			if r.min, err = parseNumberLimit(v.Min); err != nil {
+				return nil, fmt.Errorf("поле %v: min %q: %w", v.Name, v.Min, err)
+			}
+			if r.max, err = parseNumberLimit(v.Max); err != nil {
+				return nil, fmt.Errorf("поле %v: max %q: %w", v.Name, v.Max, err)
+			}
+		}
+		if len(v.Enum) > 0 {
+			r.enum = make(map[string]bool, len(v.Enum))
+			for _, e := range v.Enum {
+				r.enum[strings.TrimSpace(e)] = true
+			}
+		}
+		if v.Unique {
+			r.seen = make(map[string]int)
+		}
+		rules[key] = r
+	}
+	return rules, nil
+}
+
+func parseNumberLimit(l Limit) (*float64, error) {
+	if l == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(string(l)), 64)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func (v FieldExcel) parseDateLimit(l Limit) (*time.Time, error) {
+	if l == "" {
+		return nil, nil
+	}
+	str := strings.TrimSpace(string(l))
+	t, err := v.parseTime(str)
+	if err != nil {
+		loc, errLoc := location(v.TimeZone)
+		if errLoc != nil {
+			return nil, errLoc
+		}
+		if t, err = time.ParseInLocation("2006-01-02", str, loc); err != nil {
+			return nil, err
+		}
+	}
+	return &t, nil
+}
+
+// check проверка значения поля
+// raw - исходное значение ячейки, val - значение после преобразования типа
+func (r *fieldRule) check(raw string, val interface{}) error {
+	v := r.field
+	if strings.TrimSpace(raw) == "" {
+		if v.Required {
+			return fmt.Errorf("обязательное поле не заполнено")
+		}
+		return nil
+	}
+
+	switch {
+	case r.minDate != nil || r.maxDate != nil:
+		t, ok := val.(time.Time)
+		if !ok {
+			return fmt.Errorf("значение %v не дата", val)
+		}
+		if r.minDate != nil && t.Before(*r.minDate) {
+			return fmt.Errorf("дата раньше минимальной %v", v.Min)
+		}
+		if r.maxDate != nil && t.After(*r.maxDate) {
+			return fmt.Errorf("дата позже максимальной %v", v.Max)
+		}
+	case r.min != nil || r.max != nil:
+		n, err := numberValue(val)
+		if err != nil {
+			return err
+		}
+		if r.min != nil && n < *r.min {
+			return fmt.Errorf("значение меньше минимального %v", v.Min)
+		}
+		if r.max != nil && n > *r.max {
+			return fmt.Errorf("значение больше максимального %v", v.Max)
+		}
+	}
+
+	text := r.text(raw, val)
+	if v.MaxLength > 0 {
+		if l := utf8.RuneCountInString(text); l > v.MaxLength {
+			return fmt.Errorf("длина %d больше допустимой %d", l, v.MaxLength)
+		}
+	}
+	if r.re != nil && !r.re.MatchString(text) {
+		return fmt.Errorf("значение не соответствует шаблону %v", v.Regex)
+	}
+	if r.enum != nil && !r.enum[text] {
+		return fmt.Errorf("значение не из списка допустимых: %v", strings.Join(v.Enum, ", "))
+	}
+	if r.seen != nil {
+		if first, ok := r.seen[text]; ok {
+			return fmt.Errorf("значение повторяется (строка %d)", first)
+		}
+	}
+	return nil
+}
+
+// text значение для правил max_length, regex, enum и unique: у текстовых полей - текст ячейки,
+// у остальных - значение после преобразования типа в каноническом виде: число без ведущих нулей и
+// лишних знаков (00123 -> 123, 12.50 -> 12.5), дата в формате поля (серийный номер 45123 -> 16.07.2023)
+func (r *fieldRule) text(raw string, val interface{}) string {
+	v := r.field
+	if v.IsText() || val == nil {
+		return strings.TrimSpace(raw)
+	}
+	switch x := val.(type) {
+	case string:
+		return strings.TrimSpace(x)
+	case time.Time:
+		layout := v.ParseFormat
+		if layout == "" {
+			layout = "02.01.2006"
+		}
+		return x.Format(layout)
+	case time.Duration:
+		return FormatDuration(x)
+	case bool:
+		return strconv.FormatBool(x)
+	}
+	return toString(val, "")
+}
+
+// remember запоминаем значение для проверки unique (text); вызывается, когда вся строка прошла проверку
+func (r *fieldRule) remember(text string, rowNum int) {
+	if r.seen != nil && text != "" {
+		r.seen[text] = rowNum
+	}
+}
@@ -0,0 +1,72 @@
+package xlsx
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestValidateRules(t *testing.T) {
+	filename := writeBook(t, [][]interface{}{
+		{"Код", "Кол-во", "Дата", "Вид", "Счёт"},
+		{"ABC", 5, 45000, "a", "00123"}, // без ошибок: дата 15.03.2023, счёт 123
+		{"", 5, 45000, "a", 124},        // required
+		{"ABCDEF", 5, 45000, "a", 125},  // max_length
+		{"abc", 5, 45000, "a", 126},     // regex
+		{"ABD", 11, 45000, "a", 127},    // max
+		{"ABE", 0, 45000, "a", 128},     // min
+		{"ABF", 5, 44000, "a", 129},     // min даты: 18.06.2020
+		{"ABG", 5, 45000, "c", 130},     // enum
+		{"ABH", 5, 45000, "b", 123},     // unique: 123 уже был (00123)
+		{"ABI", 5, 45000, "b", 1234},    // max_length числа
+	})
+	fe := newTestFields(map[int]FieldExcel{
+		1: {Name: "code", Required: true, MaxLength: 5, Regex: "^[A-Z]+$"},
+		2: {Name: "qty", Type: "int64", Min: "1", Max: "10"},
+		// правила regex и max_length у дат проверяются по дате в формате поля, а не по серийному номеру
+		3: {Name: "paid", Type: "date", Min: "2023-01-01", Regex: `^\d\d\.\d\d\.2023$`, MaxLength: 10},
+		4: {Name: "kind", Enum: []string{"a", "b"}},
+		5: {Name: "account", Type: "int64", Unique: true, MaxLength: 3},
+	})
+
+	data, errs, err := fe.ExcelToDataValidate(filename, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 1 || data[0]["account"] != int64(123) {
+		t.Errorf("записи без ошибок: %v", data)
+	}
+	var got []string
+	for _, e := range errs {
+		got = append(got, e.Column+strconv.Itoa(e.Row))
+	}
+	want := []string{"A3", "A4", "A5", "B6", "B7", "C8", "D9", "E10", "E11"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ошибки в ячейках %v, ожидалось %v\n%v", got, want, errs)
+	}
+}
+
+func TestRuleText(t *testing.T) {
+	r := &fieldRule{field: FieldExcel{Type: "date", ParseFormat: "02.01.2006"}}
+	val, err := parseDateCell(r.field, "45123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.text("45123", val); got != "16.07.2023" {
+		t.Errorf("text(45123) = %q, ожидалось 16.07.2023", got)
+	}
+
+	r = &fieldRule{field: FieldExcel{Type: "decimal"}}
+	val, err = parseDecimalCell(r.field, "12.50")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.text("12.50", val); got != "12.5" {
+		t.Errorf("text(12.50) = %q, ожидалось 12.5", got)
+	}
+
+	r = &fieldRule{field: FieldExcel{}}
+	if got := r.text(" 007 ", "007"); got != "007" {
+		t.Errorf("text текстового поля = %q, ожидалось 007", got)
+	}
+}
@@ -0,0 +1,115 @@
+package xlsx
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// defaultSheetName лист, который excelize создаёт в новой книге
+const defaultSheetName = "Sheet1"
+
+// Workbook сессия работы с Excel-файлом: файл открывается один раз, чтение, запись листов
+// и сводные таблицы выполняются над общим *excelize.File, сохранение - один раз в конце.
+//
+//	wb, err := xlsx.OpenWorkbook(filename)
+//	if err != nil { ... }
+//	defer wb.Close()
+//	data, err := fe.SheetToData(wb, startData)
+//	err = feOut.DataToSheet(wb, 1, data)
+//	err = wb.Save()
+type Workbook struct {
+	f        *excelize.File
+	filename string
+	isNew    bool        // книга создана, а не прочитана из файла
+	opts     SaveOptions // настройки сохранения
+}
+
+// OpenWorkbook открывает существующий Excel-файл
+func OpenWorkbook(filename string) (*Workbook, error) {
+	f, err := excelize.OpenFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("OpenFile %v", err)
+	}
+	return &Workbook{f: f, filename: filename}, nil
+}
+
+// OpenOrNewWorkbook открывает Excel-файл, а при его отсутствии создаёт новую книгу
+// (файл появится при сохранении)
+func OpenOrNewWorkbook(filename string) (*Workbook, error) {
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return &Workbook{f: excelize.NewFile(), filename: filename, isNew: true}, nil
+	}
+	return OpenWorkbook(filename)
+}
+
+// File книга excelize для операций, которых нет в пакете
+func (wb *Workbook) File() *excelize.File {
+	return wb.f
+}
+
+// Filename имя файла книги
+func (wb *Workbook) Filename() string {
+	return wb.filename
+}
+
+// SetSaveOptions задаёт настройки сохранения книги
+func (wb *Workbook) SetSaveOptions(opts SaveOptions) {
+	wb.opts = opts
+}
+
+// Save сохраняет книгу в её файл
+func (wb *Workbook) Save() error {
+	return wb.SaveAs(wb.filename)
+}
+
+// SaveAs сохраняет книгу в файл filename (атомарно, см. writeFileAtomic)
+func (wb *Workbook) SaveAs(filename string) error {
+	if err := wb.dropDefaultSheet(); err != nil {
+		return err
+	}
+	if err := saveWorkbook(wb.f, filename, wb.opts); err != nil {
+		return fmt.Errorf("SaveAs %v", err)
+	}
+	wb.filename = filename
+	wb.isNew = false
+	return nil
+}
+
+// Close закрывает книгу без сохранения
+func (wb *Workbook) Close() error {
+	return wb.f.Close()
+}
+
+// newSheet создаём лист name, существующий лист с тем же именем пересоздаётся
+func (wb *Workbook) newSheet(name string) error {
+	if index, _ := wb.f.GetSheetIndex(name); index != -1 {
+		if err := wb.f.DeleteSheet(name); err != nil {
+			return fmt.Errorf("DeleteSheet %v", err)
+		}
+	}
+	if _, err := wb.f.NewSheet(name); err != nil {
+		return fmt.Errorf("NewSheet %v", err)
+	}
+	return nil
+}
+
+// dropDefaultSheet в новой книге удаляем пустой лист по умолчанию, если есть другие листы
+func (wb *Workbook) dropDefaultSheet() error {
+	if !wb.isNew || wb.f.SheetCount < 2 {
+		return nil
+	}
+	if index, _ := wb.f.GetSheetIndex(defaultSheetName); index == -1 {
+		return nil
+	}
+	rows, err := wb.f.GetRows(defaultSheetName)
+	if err != nil || len(rows) > 0 {
+		return err
+	}
+	if err = wb.f.DeleteSheet(defaultSheetName); err != nil {
+		return err
+	}
+	wb.f.SetActiveSheet(0)
+	return nil
+}
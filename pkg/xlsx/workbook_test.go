@@ -0,0 +1,75 @@
+package xlsx
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestWorkbookSession(t *testing.T) {
+	filename := writeBook(t, [][]interface{}{{"Ф.И.О.", "Сумма"}, {"Иванов", 10}, {"Петров", 20}})
+
+	wb, err := OpenWorkbook(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	fr := newTestFields(map[int]FieldExcel{1: {Name: "fio"}, 2: {Name: "sum", Type: "int64"}})
+	data, err := fr.SheetToData(wb, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw := NewFieldsExcel("Вывод", map[int]FieldExcel{
+		1: {Name: "sum", Header: "Сумма"},
+		2: {Name: "fio", Header: "Ф.И.О."},
+	}, nopLogger{})
+	if err = fw.DataToSheet(wb, 1, data); err != nil {
+		t.Fatal(err)
+	}
+	if err = wb.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := excelize.OpenFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if got, want := f.GetSheetList(), []string{"Лист1", "Вывод"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("листы %q, ожидалось %q", got, want)
+	}
+	rows, _ := f.GetRows("Вывод")
+	if want := [][]string{{"Сумма", "Ф.И.О."}, {"10", "Иванов"}, {"20", "Петров"}}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("лист Вывод: %q, ожидалось %q", rows, want)
+	}
+	if rows, _ = f.GetRows("Лист1"); len(rows) != 3 || rows[1][0] != "Иванов" {
+		t.Errorf("лист Лист1 изменился: %q", rows)
+	}
+}
+
+func TestNewWorkbookDropsDefaultSheet(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "new.xlsx")
+	wb, err := OpenOrNewWorkbook(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	fw := NewFieldsExcel("Вывод", map[int]FieldExcel{1: {Name: "fio", Header: "Ф.И.О."}}, nopLogger{})
+	if err = fw.DataToSheet(wb, 1, []map[string]interface{}{{"fio": "Иванов"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = wb.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := excelize.OpenFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if got := f.GetSheetList(); !reflect.DeepEqual(got, []string{"Вывод"}) {
+		t.Errorf("листы новой книги %q, ожидалось [Вывод]", got)
+	}
+}
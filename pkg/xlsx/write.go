@@ -2,7 +2,7 @@ package xlsx
 
 import (
 	"fmt"
-	"os"
+	"io"
 	"strconv"
 	"time"
 
@@ -11,47 +11,81 @@ import (
 
 // DataToExcel Записываем данные в Excel-файл
 func (s *FieldsExcel) DataToExcel(filename string, startRow int, data []map[string]interface{}) error {
-	var (
-		err error
-		f   *excelize.File
-	)
-	if startRow == 0 {
-		startRow = 1
+	_, err := s.dataToExcel(filename, startRow, sliceRecords(data), false)
+	return err
+}
+
+// DataToExcelValidate Записываем данные в Excel-файл с проверкой всех записей.
+// Записи с ошибками не выводятся, ошибки по каждой ячейке собираются в список.
+func (s *FieldsExcel) DataToExcelValidate(filename string, startRow int, data []map[string]interface{}) (CellErrors, error) {
+	return s.dataToExcel(filename, startRow, sliceRecords(data), true)
+}
+
+// DataToSheet Записываем данные на лист открытой книги (сохранение - Workbook.Save)
+func (s *FieldsExcel) DataToSheet(wb *Workbook, startRow int, data []map[string]interface{}) error {
+	_, err := s.dataToSheet(wb, startRow, sliceRecords(data), false)
+	return err
+}
+
+// DataToSheetValidate Записываем данные на лист открытой книги с проверкой всех записей (см. DataToExcelValidate)
+func (s *FieldsExcel) DataToSheetValidate(wb *Workbook, startRow int, data []map[string]interface{}) (CellErrors, error) {
+	return s.dataToSheet(wb, startRow, sliceRecords(data), true)
+}
+
+// dataToExcel запись данных, collect - собирать ошибки ячеек вместо прерывания записи
+func (s *FieldsExcel) dataToExcel(filename string, startRow int, next NextRecord, collect bool) (CellErrors, error) {
+	if s.isCSV(filename) {
+		return s.dataToCSV(filename, next, collect)
 	}
-	if s.sheetName == "" {
-		s.sheetName = "Новый лист"
+	if s.isJSON(filename) {
+		return s.dataToJSON(filename, next, collect)
 	}
-	if _, err = os.Stat(filename); os.IsNotExist(err) {
-		// файл не существует
-		f = excelize.NewFile()
-		f.SetSheetName("Sheet1", s.sheetName) // лист по умолчанию переименовываем
-		s.log.Debugf("NewFile sheetName=%v", s.sheetName)
-	} else {
-		// файл существует
-		f, err = excelize.OpenFile(filename)
-		if err != nil {
-			return fmt.Errorf("OpenFile %v", err)
-		}
-		if index, _ := f.GetSheetIndex(s.sheetName); index != -1 {
-			f.DeleteSheet(s.sheetName)
-		}
-		f.NewSheet(s.sheetName)
-		s.log.Debugf("OpenFile sheetName=%v", s.sheetName)
+	if s.fileFormat(filename) == FormatXLS {
+		return nil, fmt.Errorf("запись в формат xls не поддерживается: %v", filename)
+	}
+	wb, err := OpenOrNewWorkbook(filename)
+	if err != nil {
+		return nil, err
 	}
+	wb.SetSaveOptions(s.save)
 	defer func() {
-		if err := f.Close(); err != nil {
+		if err := wb.Close(); err != nil {
 			s.log.Error(err)
 		}
 	}()
+	errs, err := s.dataToSheet(wb, startRow, next, collect)
+	if err != nil {
+		return nil, err
+	}
+	if err = wb.Save(); err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
+
+// dataToSheet запись данных на лист книги
+func (s *FieldsExcel) dataToSheet(wb *Workbook, startRow int, next NextRecord, collect bool) (CellErrors, error) {
+	if startRow == 0 {
+		startRow = 1
+	}
+	if s.sheetName == "" {
+		s.sheetName = "Новый лист"
+	}
+	f := wb.f
+	if err := wb.newSheet(s.sheetName); err != nil {
+		return nil, err
+	}
+	s.log.Debugf("sheetName=%v", s.sheetName)
+
 	// создание streamWriter для буферизированной записи
 	streamWriter, err := f.NewStreamWriter(s.sheetName)
 	if err != nil {
-		return fmt.Errorf("NewStreamWriter %v", err)
+		return nil, fmt.Errorf("NewStreamWriter %v", err)
 	}
 
 	headersColumns := s.fields
 	if err = s.CreateStyle(f); err != nil {
-		return err
+		return nil, err
 	}
 	countColumn := s.CountColumn()
 	s.log.Debugf("countColumn=%v", countColumn)
@@ -62,7 +96,7 @@ func (s *FieldsExcel) DataToExcel(filename string, startRow int, data []map[stri
 	for i, v := range headersColumns {
 		if v.Width > 0 {
 			if err = streamWriter.SetColWidth(i, i, v.Width); err != nil {
-				return fmt.Errorf("SetColWidth %v", err)
+				return nil, fmt.Errorf("SetColWidth %v", err)
 			}
 		}
 	}
@@ -84,75 +118,49 @@ func (s *FieldsExcel) DataToExcel(filename string, startRow int, data []map[stri
 
 	// пишем строку заголовка
 	if err := streamWriter.SetRow(addrStart, strColumns); err != nil {
-		return fmt.Errorf("SetRow addrStart %v", err)
+		return nil, fmt.Errorf("SetRow addrStart %v", err)
 	}
 
 	startData := startRow + 1 // шапка + заголовок
-	countData := len(data)
-	s.log.Debugf("countData=%v", countData)
+	countData := 0            // кол-во записанных строк
+	var errs CellErrors
 	// Пишем данные
-	for r, row := range data {
+	for r := 0; ; r++ {
+		row, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
 
 		// формируем строку данных заданного формата
-		rowVal := make([]interface{}, maxColumn)
-		for i := 0; i < maxColumn; i++ {
-
-			if v, ok := headersColumns[i+1]; ok {
-				val := row[v.Name]
-
-				if v.Type == "float64" {
-					if _, ok := val.(float64); !ok {
-						res, err := strconv.ParseFloat(val.(string), 64)
-						if err != nil {
-							return err
-						}
-						val = res
-					}
-				}
-				if v.Type == "date" {
-					if _, ok := val.(time.Time); !ok {
-						valStr := val.(string)
-						t, err := time.Parse(v.ParseFormat, valStr)
-						if err != nil {
-							s.log.Debugf("time.Parse ParseFormat=%v val=%v error: %v", v.ParseFormat, valStr, err)
-							resFloat, err := strconv.ParseFloat(valStr, 64)
-							if err != nil {
-								return err
-							}
-							res, err := excelize.ExcelDateToTime(resFloat, false)
-							if err != nil {
-								return err
-							}
-							val = res
-						} else {
-							val = t
-						}
-					}
-				}
-				if v.Type == "int64" {
-					if _, ok := val.(int64); !ok {
-						res, err := strconv.ParseInt(val.(string), 10, 64)
-						if err != nil {
-							return err
-						}
-						val = res
-					}
-				}
-				rowVal[i] = excelize.Cell{StyleID: v.StyleID, Value: val}
-			} else {
-				rowVal[i] = nil
+		rowVal, rowErrs := s.recordToRow(row, maxColumn, r+1)
+		if len(rowErrs) > 0 {
+			if !collect {
+				return nil, rowErrs
+			}
+			errs = append(errs, rowErrs...) // строку с ошибками пропускаем
+			continue
+		}
+		for i, cell := range rowVal {
+			if c, ok := cell.(excelize.Cell); ok {
+				c.Value = excelValue(s.fields[i+1], c.Value)
+				rowVal[i] = c
 			}
 		}
 		// пишем строку данных
-		addr, _ := excelize.CoordinatesToCellName(1, r+startData)
+		addr, _ := excelize.CoordinatesToCellName(1, countData+startData)
 		if err := streamWriter.SetRow(addr, rowVal); err != nil {
-			return fmt.Errorf("SetRow %v", err)
+			return nil, fmt.Errorf("SetRow %v", err)
 		}
+		countData++
 
 		if r%1000 == 0 {
 			fmt.Printf("Сохраняем данные в файл...%v\r", r)
 		}
 	}
+	s.log.Debugf("countData=%v", countData)
 	//============= создаём умную таблицу
 	letterLastColumn, _ := excelize.ColumnNumberToName(maxColumn)
 	rangeRef := fmt.Sprintf("%s:%s%d", addrStart, letterLastColumn, countData+startRow)
@@ -168,19 +176,16 @@ func (s *FieldsExcel) DataToExcel(filename string, startRow int, data []map[stri
 		ShowRowStripes:    &disable,
 		ShowColumnStripes: false,
 	}); err != nil {
-		return fmt.Errorf("AddTable %v %w", tableName, err)
+		return nil, fmt.Errorf("AddTable %v %w", tableName, err)
 	}
 
 	//==========================================
 	f.SetActiveSheet(0)
 
 	if err := streamWriter.Flush(); err != nil {
-		return fmt.Errorf("flush %v", err)
+		return nil, fmt.Errorf("flush %v", err)
 	}
-	if err := f.SaveAs(filename); err != nil {
-		return err
-	}
-	return nil
+	return errs, nil
 }
 
 // CreatePivotTableFile ...
@@ -192,16 +197,32 @@ func (s *FieldsExcel) CreatePivotTableFile(filename, sheetNamePivot string,
 	PivotTableData []excelize.PivotTableField,
 ) error {
 	//==========================================
-	f, err := excelize.OpenFile(filename)
+	wb, err := OpenWorkbook(filename)
 	if err != nil {
-		return fmt.Errorf("OpenFile %v", err)
+		return err
+	}
+	defer wb.Close()
+	wb.SetSaveOptions(s.save)
+
+	if err := s.CreatePivotTable(wb, sheetNamePivot, DataRange, PivotTableRange,
+		PivotTableRows, PivotTableFilter, PivotTableColumns, PivotTableData); err != nil {
+		return err
 	}
-	defer f.Close()
+	return wb.Save()
+}
 
-	if index, _ := f.GetSheetIndex(sheetNamePivot); index != -1 {
-		f.DeleteSheet(sheetNamePivot)
+// CreatePivotTable создаём лист sheetNamePivot со сводной таблицей в открытой книге (см. CreatePivotTableFile)
+func (s *FieldsExcel) CreatePivotTable(wb *Workbook, sheetNamePivot string,
+	DataRange, PivotTableRange string,
+	PivotTableRows []excelize.PivotTableField,
+	PivotTableFilter []excelize.PivotTableField,
+	PivotTableColumns []excelize.PivotTableField,
+	PivotTableData []excelize.PivotTableField,
+) error {
+	f := wb.f
+	if err := wb.newSheet(sheetNamePivot); err != nil {
+		return err
 	}
-	f.NewSheet(sheetNamePivot)
 	if err := f.AddPivotTable(&excelize.PivotTableOptions{
 		DataRange:       DataRange,
 		PivotTableRange: PivotTableRange,
@@ -225,9 +246,61 @@ func (s *FieldsExcel) CreatePivotTableFile(filename, sheetNamePivot string,
 		return err
 	}
 	f.SetActiveSheet(0)
-	if err := f.SaveAs(filename); err != nil {
-		return fmt.Errorf("SaveAs %v", err)
-	}
 
 	return nil
 }
+
+// recordToRow формируем строку для вывода из записи
+// recNum - номер записи в данных (для ошибок)
+func (s FieldsExcel) recordToRow(record map[string]interface{}, maxColumn, recNum int) ([]interface{}, CellErrors) {
+	var errs CellErrors
+	rowVal := make([]interface{}, maxColumn)
+	for i := 0; i < maxColumn; i++ {
+		v, ok := s.fields[i+1]
+		if !ok {
+			continue
+		}
+		val, err := valueToCell(v, record[v.Name])
+		if err != nil {
+			errs = append(errs, s.newCellError(recNum, i+1, v, record[v.Name], err))
+			continue
+		}
+		rowVal[i] = excelize.Cell{StyleID: v.StyleID, Value: val}
+	}
+	return rowVal, errs
+}
+
+// valueToCell приводим значение к типу поля для вывода в ячейку (Converter.Format)
+func valueToCell(v FieldExcel, val interface{}) (interface{}, error) {
+	if val == nil {
+		return nil, nil
+	}
+	c, ok := converter(v.Type)
+	if !ok {
+		return nil, fmt.Errorf("неизвестный тип поля %q", v.Type)
+	}
+	if c.Validate != nil {
+		if err := c.Validate(v, val); err != nil {
+			return nil, err
+		}
+	}
+	if c.Format == nil {
+		return val, nil
+	}
+	return c.Format(v, val)
+}
+
+// excelValue значение для ячейки Excel: точные числа - float64, длительность и время суток - доля суток
+func excelValue(v FieldExcel, val interface{}) interface{} {
+	switch x := val.(type) {
+	case Decimal:
+		return x.Float64()
+	case time.Duration:
+		return durationToDays(x)
+	case time.Time:
+		if v.Type == "time" {
+			return durationToDays(timeOfDay(x))
+		}
+	}
+	return val
+}
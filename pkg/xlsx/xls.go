@@ -0,0 +1,531 @@
+package xlsx
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"unicode/utf16"
+
+	"github.com/richardlehane/mscfb"
+)
+
+// Чтение файлов Excel 97-2003 (.xls, формат BIFF8).
+// Файл - составной документ OLE2, данные книги в потоке "Workbook".
+// Значения ячеек возвращаются как в режиме RawCellValue для xlsx:
+// числа и даты - числом, строки - текстом.
+
+// Типы записей BIFF8
+const (
+	xlsRecFormula    = 0x0006
+	xlsRecEOF        = 0x000A
+	xlsRecDateMode   = 0x0022
+	xlsRecFilePass   = 0x002F
+	xlsRecContinue   = 0x003C
+	xlsRecBoundSheet = 0x0085
+	xlsRecMulRK      = 0x00BD
+	xlsRecRString    = 0x00D6
+	xlsRecSST        = 0x00FC
+	xlsRecLabelSST   = 0x00FD
+	xlsRecNumber     = 0x0203
+	xlsRecLabel      = 0x0204
+	xlsRecBoolErr    = 0x0205
+	xlsRecString     = 0x0207
+	xlsRecRK         = 0x027E
+	xlsRecBOF        = 0x0809
+)
+
+// xlsErrors коды ошибок ячеек
+var xlsErrors = map[byte]string{
+	0x00: "#NULL!", 0x07: "#DIV/0!", 0x0F: "#VALUE!", 0x17: "#REF!",
+	0x1D: "#NAME?", 0x24: "#NUM!", 0x2A: "#N/A",
+}
+
+// xlsRecord запись потока книги
+type xlsRecord struct {
+	typ  uint16
+	data []byte
+}
+
+// xlsSheet описание листа книги
+type xlsSheet struct {
+	name   string
+	offset uint32 // смещение записи BOF листа в потоке
+	typ    byte   // 0 - рабочий лист
+}
+
+// xlsWorkbook книга Excel 97-2003
+type xlsWorkbook struct {
+	stream   []byte
+	sheets   []xlsSheet
+	sst      []string
+	date1904 bool
+}
+
+// openXLS открываем и разбираем xls-файл
+func openXLS(filename string) (*xlsWorkbook, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	doc, err := mscfb.New(file)
+	if err != nil {
+		return nil, fmt.Errorf("файл не является документом Excel 97-2003: %w", err)
+	}
+	var (
+		stream  []byte
+		isBIFF5 bool
+	)
+	for entry, err := doc.Next(); err == nil; entry, err = doc.Next() {
+		switch entry.Name {
+		case "Workbook":
+			if stream, err = io.ReadAll(entry); err != nil {
+				return nil, err
+			}
+		case "Book":
+			isBIFF5 = true
+		}
+	}
+	if stream == nil {
+		if isBIFF5 {
+			return nil, errors.New("формат Excel 5.0/95 (BIFF5) не поддерживается")
+		}
+		return nil, errors.New("в файле нет потока Workbook")
+	}
+
+	wb := &xlsWorkbook{stream: stream}
+	if err = wb.readGlobals(); err != nil {
+		return nil, err
+	}
+	return wb, nil
+}
+
+// readRecord читаем запись потока по смещению, возвращаем запись и смещение следующей
+func (wb *xlsWorkbook) readRecord(offset int) (xlsRecord, int, error) {
+	if offset+4 > len(wb.stream) {
+		return xlsRecord{}, offset, io.EOF
+	}
+	typ := binary.LittleEndian.Uint16(wb.stream[offset:])
+	size := int(binary.LittleEndian.Uint16(wb.stream[offset+2:]))
+	next := offset + 4 + size
+	if next > len(wb.stream) {
+		return xlsRecord{}, offset, io.ErrUnexpectedEOF
+	}
+	return xlsRecord{typ: typ, data: wb.stream[offset+4 : next]}, next, nil
+}
+
+// readContinues собираем записи CONTINUE, следующие за offset
+func (wb *xlsWorkbook) readContinues(offset int) ([][]byte, int) {
+	var parts [][]byte
+	for {
+		rec, next, err := wb.readRecord(offset)
+		if err != nil || rec.typ != xlsRecContinue {
+			return parts, offset
+		}
+		parts = append(parts, rec.data)
+		offset = next
+	}
+}
+
+// readGlobals разбираем общую часть книги: листы, таблицу строк, систему дат
+func (wb *xlsWorkbook) readGlobals() error {
+	rec, offset, err := wb.readRecord(0)
+	if err != nil || rec.typ != xlsRecBOF || len(rec.data) < 2 {
+		return errors.New("неверный формат потока Workbook")
+	}
+	if version := binary.LittleEndian.Uint16(rec.data); version != 0x0600 {
+		return fmt.Errorf("версия BIFF %#x не поддерживается (нужен BIFF8)", version)
+	}
+	for {
+		if rec, offset, err = wb.readRecord(offset); err != nil {
+			return fmt.Errorf("поток Workbook: %w", err)
+		}
+		switch rec.typ {
+		case xlsRecEOF:
+			return nil
+		case xlsRecFilePass:
+			return errors.New("зашифрованные xls-файлы не поддерживаются")
+		case xlsRecDateMode:
+			wb.date1904 = len(rec.data) >= 2 && binary.LittleEndian.Uint16(rec.data) == 1
+		case xlsRecBoundSheet:
+			if len(rec.data) < 8 {
+				return errors.New("неверная запись BOUNDSHEET")
+			}
+			r := &xlsStringReader{parts: [][]byte{rec.data[7:]}}
+			name, err := r.readString(int(rec.data[6]))
+			if err != nil {
+				return fmt.Errorf("имя листа: %w", err)
+			}
+			wb.sheets = append(wb.sheets, xlsSheet{
+				name:   name,
+				offset: binary.LittleEndian.Uint32(rec.data),
+				typ:    rec.data[5],
+			})
+		case xlsRecSST:
+			parts, next := wb.readContinues(offset)
+			offset = next
+			if err = wb.readSST(append([][]byte{rec.data}, parts...)); err != nil {
+				return fmt.Errorf("таблица строк: %w", err)
+			}
+		}
+	}
+}
+
+// readSST разбираем общую таблицу строк (SST + CONTINUE)
+func (wb *xlsWorkbook) readSST(parts [][]byte) error {
+	if len(parts[0]) < 8 {
+		return errors.New("неверная запись SST")
+	}
+	count := int(binary.LittleEndian.Uint32(parts[0][4:]))
+	parts[0] = parts[0][8:]
+	r := &xlsStringReader{parts: parts}
+	wb.sst = make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		cch, err := r.readUint16()
+		if err != nil {
+			return err
+		}
+		str, err := r.readRichString(int(cch))
+		if err != nil {
+			return err
+		}
+		wb.sst = append(wb.sst, str)
+	}
+	return nil
+}
+
+// SheetList имена рабочих листов книги
+func (wb *xlsWorkbook) SheetList() []string {
+	names := make([]string, 0, len(wb.sheets))
+	for _, sh := range wb.sheets {
+		if sh.typ == 0 {
+			names = append(names, sh.name)
+		}
+	}
+	return names
+}
+
+// GetRows значения ячеек листа по строкам (пустые строки - пустые срезы)
+func (wb *xlsWorkbook) GetRows(sheetName string) ([][]string, error) {
+	var sheet *xlsSheet
+	for i := range wb.sheets {
+		if wb.sheets[i].name == sheetName && wb.sheets[i].typ == 0 {
+			sheet = &wb.sheets[i]
+			break
+		}
+	}
+	if sheet == nil {
+		return nil, fmt.Errorf("лист %s не найден", sheetName)
+	}
+
+	rec, offset, err := wb.readRecord(int(sheet.offset))
+	if err != nil || rec.typ != xlsRecBOF {
+		return nil, fmt.Errorf("лист %s: неверное начало листа", sheetName)
+	}
+
+	var (
+		rows       [][]string
+		formulaRow = -1 // ячейка формулы, ожидающая запись STRING
+		formulaCol int
+	)
+	setCell := func(row, col int, val string) {
+		for len(rows) <= row {
+			rows = append(rows, nil)
+		}
+		for len(rows[row]) <= col {
+			rows[row] = append(rows[row], "")
+		}
+		rows[row][col] = val
+	}
+
+	for {
+		if rec, offset, err = wb.readRecord(offset); err != nil {
+			return nil, fmt.Errorf("лист %s: %w", sheetName, err)
+		}
+		data := rec.data
+		if rec.typ != xlsRecEOF && rec.typ != xlsRecContinue && rec.typ != xlsRecString && len(data) < 6 {
+			continue
+		}
+		switch rec.typ {
+		case xlsRecEOF:
+			return rows, nil
+		case xlsRecLabelSST:
+			if len(data) < 10 {
+				continue
+			}
+			idx := int(binary.LittleEndian.Uint32(data[6:]))
+			if idx < len(wb.sst) {
+				setCell(int(binary.LittleEndian.Uint16(data)), int(binary.LittleEndian.Uint16(data[2:])), wb.sst[idx])
+			}
+		case xlsRecLabel, xlsRecRString:
+			parts, next := wb.readContinues(offset)
+			offset = next
+			r := &xlsStringReader{parts: append([][]byte{data[6:]}, parts...)}
+			cch, err := r.readUint16()
+			if err != nil {
+				return nil, err
+			}
+			str, err := r.readString(int(cch))
+			if err != nil {
+				return nil, err
+			}
+			setCell(int(binary.LittleEndian.Uint16(data)), int(binary.LittleEndian.Uint16(data[2:])), str)
+		case xlsRecNumber:
+			if len(data) < 14 {
+				continue
+			}
+			num := math.Float64frombits(binary.LittleEndian.Uint64(data[6:]))
+			setCell(int(binary.LittleEndian.Uint16(data)), int(binary.LittleEndian.Uint16(data[2:])), formatXLSNumber(num))
+		case xlsRecRK:
+			if len(data) < 10 {
+				continue
+			}
+			num := decodeRK(binary.LittleEndian.Uint32(data[6:]))
+			setCell(int(binary.LittleEndian.Uint16(data)), int(binary.LittleEndian.Uint16(data[2:])), formatXLSNumber(num))
+		case xlsRecMulRK:
+			row := int(binary.LittleEndian.Uint16(data))
+			col := int(binary.LittleEndian.Uint16(data[2:]))
+			for pos := 4; pos+6 <= len(data)-2; pos += 6 {
+				num := decodeRK(binary.LittleEndian.Uint32(data[pos+2:]))
+				setCell(row, col, formatXLSNumber(num))
+				col++
+			}
+		case xlsRecBoolErr:
+			if len(data) < 8 {
+				continue
+			}
+			setCell(int(binary.LittleEndian.Uint16(data)), int(binary.LittleEndian.Uint16(data[2:])), xlsBoolErr(data[6], data[7]))
+		case xlsRecFormula:
+			if len(data) < 14 {
+				continue
+			}
+			row := int(binary.LittleEndian.Uint16(data))
+			col := int(binary.LittleEndian.Uint16(data[2:]))
+			val := data[6:14]
+			if binary.LittleEndian.Uint16(val[6:]) != 0xFFFF { // число
+				setCell(row, col, formatXLSNumber(math.Float64frombits(binary.LittleEndian.Uint64(val))))
+				continue
+			}
+			switch val[0] {
+			case 0: // строка в следующей записи STRING
+				formulaRow, formulaCol = row, col
+			case 1:
+				setCell(row, col, xlsBoolErr(val[2], 0))
+			case 2:
+				setCell(row, col, xlsBoolErr(val[2], 1))
+			}
+		case xlsRecString:
+			if formulaRow < 0 {
+				continue
+			}
+			parts, next := wb.readContinues(offset)
+			offset = next
+			r := &xlsStringReader{parts: append([][]byte{data}, parts...)}
+			cch, err := r.readUint16()
+			if err != nil {
+				return nil, err
+			}
+			str, err := r.readString(int(cch))
+			if err != nil {
+				return nil, err
+			}
+			setCell(formulaRow, formulaCol, str)
+			formulaRow = -1
+		}
+	}
+}
+
+// xlsSource строки листа xls-файла
+type xlsSource struct {
+	rows [][]string
+	cur  int
+}
+
+// openXLS открываем лист xls-файла для чтения
+func (s *FieldsExcel) openXLS(filename string) (*xlsSource, error) {
+	wb, err := openXLS(filename)
+	if err != nil {
+		return nil, err
+	}
+	if s.sheetName == "" {
+		sheets := wb.SheetList()
+		if len(sheets) == 0 {
+			return nil, errors.New("в файле нет листов")
+		}
+		s.sheetName = sheets[0] // получаем имя 1 листа
+	}
+	rows, err := wb.GetRows(s.sheetName)
+	if err != nil {
+		return nil, err
+	}
+	s.date1904 = wb.date1904
+	return &xlsSource{rows: rows, cur: -1}, nil
+}
+
+func (src *xlsSource) Next() bool {
+	src.cur++
+	return src.cur < len(src.rows)
+}
+
+func (src *xlsSource) Columns() ([]string, error) {
+	return src.rows[src.cur], nil
+}
+
+func (src *xlsSource) Close() error {
+	src.rows = nil
+	return nil
+}
+
+// decodeRK число в формате RK
+func decodeRK(rk uint32) float64 {
+	var num float64
+	if rk&0x02 != 0 { // целое
+		num = float64(int32(rk) >> 2)
+	} else {
+		num = math.Float64frombits(uint64(rk&0xFFFFFFFC) << 32)
+	}
+	if rk&0x01 != 0 {
+		num /= 100
+	}
+	return num
+}
+
+// formatXLSNumber число в виде строки, как хранится в xlsx
+func formatXLSNumber(num float64) string {
+	return strconv.FormatFloat(num, 'f', -1, 64)
+}
+
+// xlsBoolErr значение логической ячейки или ячейки с ошибкой
+func xlsBoolErr(val, isErr byte) string {
+	if isErr != 0 {
+		if str, ok := xlsErrors[val]; ok {
+			return str
+		}
+		return "#ERR!"
+	}
+	if val != 0 {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+// xlsStringReader чтение строк, разбитых на записи CONTINUE
+type xlsStringReader struct {
+	parts [][]byte
+	part  int
+	pos   int
+}
+
+// next переходим к следующей части, если текущая прочитана
+func (r *xlsStringReader) next() bool {
+	for r.part < len(r.parts) && r.pos >= len(r.parts[r.part]) {
+		r.part++
+		r.pos = 0
+	}
+	return r.part < len(r.parts)
+}
+
+func (r *xlsStringReader) readBytes(n int) ([]byte, error) {
+	res := make([]byte, 0, n)
+	for len(res) < n {
+		if !r.next() {
+			return nil, io.ErrUnexpectedEOF
+		}
+		chunk := r.parts[r.part][r.pos:]
+		if len(chunk) > n-len(res) {
+			chunk = chunk[:n-len(res)]
+		}
+		res = append(res, chunk...)
+		r.pos += len(chunk)
+	}
+	return res, nil
+}
+
+func (r *xlsStringReader) readUint16() (uint16, error) {
+	b, err := r.readBytes(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(b), nil
+}
+
+func (r *xlsStringReader) readUint32() (uint32, error) {
+	b, err := r.readBytes(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+// readRichString читаем строку XLUnicodeRichExtendedString (длина уже прочитана)
+func (r *xlsStringReader) readRichString(cch int) (string, error) {
+	flags, err := r.readBytes(1)
+	if err != nil {
+		return "", err
+	}
+	var runs, ext int
+	if flags[0]&0x08 != 0 { // форматированный текст
+		n, err := r.readUint16()
+		if err != nil {
+			return "", err
+		}
+		runs = int(n)
+	}
+	if flags[0]&0x04 != 0 { // фонетическая информация
+		n, err := r.readUint32()
+		if err != nil {
+			return "", err
+		}
+		ext = int(n)
+	}
+	str, err := r.readChars(cch, flags[0]&0x01 != 0)
+	if err != nil {
+		return "", err
+	}
+	if _, err = r.readBytes(runs*4 + ext); err != nil {
+		return "", err
+	}
+	return str, nil
+}
+
+// readString читаем строку XLUnicodeString: байт флагов и символы (длина уже прочитана)
+func (r *xlsStringReader) readString(cch int) (string, error) {
+	flags, err := r.readBytes(1)
+	if err != nil {
+		return "", err
+	}
+	return r.readChars(cch, flags[0]&0x01 != 0)
+}
+
+// readChars читаем cch символов; на границе записи CONTINUE заново читается байт флагов
+func (r *xlsStringReader) readChars(cch int, high bool) (string, error) {
+	chars := make([]uint16, 0, cch)
+	for len(chars) < cch {
+		if r.part < len(r.parts) && r.pos >= len(r.parts[r.part]) {
+			if !r.next() {
+				return "", io.ErrUnexpectedEOF
+			}
+			high = r.parts[r.part][r.pos]&0x01 != 0 // новая часть начинается с байта флагов
+			r.pos++
+		}
+		if !r.next() {
+			return "", io.ErrUnexpectedEOF
+		}
+		if high {
+			b, err := r.readBytes(2)
+			if err != nil {
+				return "", err
+			}
+			chars = append(chars, binary.LittleEndian.Uint16(b))
+		} else {
+			chars = append(chars, uint16(r.parts[r.part][r.pos]))
+			r.pos++
+		}
+	}
+	return string(utf16.Decode(chars)), nil
+}
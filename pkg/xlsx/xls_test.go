@@ -0,0 +1,65 @@
+package xlsx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOpenXLS(t *testing.T) {
+	wb, err := openXLS("testdata/sample.xls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !wb.date1904 {
+		t.Error("date1904: ожидалась система дат 1904")
+	}
+	if got, want := wb.SheetList(), []string{"Лист1", "Second"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SheetList() = %q, ожидалось %q", got, want)
+	}
+
+	rows, err := wb.GetRows("Лист1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"Название", "Amount", "Флаг"},        // LABELSST, LABEL
+		{"Итого: abc def", "1234.56", "TRUE"}, // SST + CONTINUE, NUMBER, BOOLERR
+		nil,                             // пустая строка
+		{"жирный", "42", "#DIV/0!"},     // форматированная строка SST, RK, ошибка
+		{"12.34", "-7", "0.5"},          // MULRK
+		{"итог", "3.5", "TRUE", "#N/A"}, // FORMULA + STRING, число, логическое, ошибка
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("GetRows() = %q, ожидалось %q", rows, want)
+	}
+
+	rows, err = wb.GetRows("Second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]string{{"Second A1"}}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("GetRows(Second) = %q, ожидалось %q", rows, want)
+	}
+
+	if _, err = wb.GetRows("Диаграмма"); err == nil {
+		t.Error("GetRows(Диаграмма): ожидалась ошибка для листа диаграммы")
+	}
+}
+
+func TestDecodeRK(t *testing.T) {
+	tests := []struct {
+		rk   uint32
+		want float64
+	}{
+		{42<<2 | 0x02, 42},
+		{1234<<2 | 0x03, 12.34},
+		{0x3FF00000, 1},
+		{0x3FF00001, 0.01},
+		{uint32(0xFFFFFFE6), -7},
+	}
+	for _, tt := range tests {
+		if got := decodeRK(tt.rk); got != tt.want {
+			t.Errorf("decodeRK(%#x) = %v, ожидалось %v", tt.rk, got, tt.want)
+		}
+	}
+}
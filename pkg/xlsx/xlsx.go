@@ -28,8 +28,6 @@ import (
 	"fmt"
 	"math/rand"
 	"reflect"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/xuri/excelize/v2"
@@ -46,13 +44,31 @@ type Logger interface {
 
 // FieldExcel структура для колонки excel-файла
 type FieldExcel struct {
-	Name        string  `json:"name"`             // имя поля, имя колонки в базе данных (на ENG)
-	Header      string  `json:"header,omitempty"` // заголовок колонки
-	Width       float64 `json:"width,omitempty"`  // ширина колонки
-	Format      string  `json:"format,omitempty"` // формат вывода
-	Type        string  `json:"type,omitempty"`   // типы данных  int64, float64, date
-	ParseFormat string  `json:"parse,omitempty"`  // формат для разбора входных значений
-	StyleID     int     `json:"style_id"`         // код стиля в файле (служебное поле, используется для вывода)
+	Name        string   `json:"name"`              // имя поля, имя колонки в базе данных (на ENG)
+	Header      string   `json:"header,omitempty"`  // заголовок колонки
+	Aliases     []string `json:"aliases,omitempty"` // другие варианты заголовка колонки (для поиска по заголовкам)
+	Width       float64  `json:"width,omitempty"`   // ширина колонки
+	Format      string   `json:"format,omitempty"`  // формат вывода
+	Type        string   `json:"type,omitempty"`    // тип данных: string, int64, float64, decimal, currency, percent, bool, date, datetime, time, duration или зарегистрированный RegisterType
+	ParseFormat string   `json:"parse,omitempty"`   // формат для разбора входных значений
+	// другие форматы разбора дат текстом (Excel dd.mm.yyyy или Go 02.01.2006), пробуются по порядку после parse
+	ParseFormats []string `json:"parse_formats,omitempty"`
+	TimeZone     string   `json:"time_zone,omitempty"` // часовой пояс дат и времени (Europe/Moscow), по умолчанию UTC
+	StyleID      int      `json:"style_id"`            // код стиля в файле (служебное поле, используется для вывода)
+	True         []string `json:"true,omitempty"`      // слова для значения "истина" у типа bool (по умолчанию да, yes, true, 1 ...)
+	False        []string `json:"false,omitempty"`     // слова для значения "ложь" у типа bool (по умолчанию нет, no, false, 0 ...)
+	Value        string   `json:"value,omitempty"`     // что читать из ячейки: raw, formatted, both (по умолчанию - как у листа, SetValueMode)
+
+	// правила проверки значений при чтении
+	Required  bool     `json:"required,omitempty"`   // значение обязательно
+	Min       Limit    `json:"min,omitempty"`        // минимальное значение (число или дата)
+	Max       Limit    `json:"max,omitempty"`        // максимальное значение (число или дата)
+	Regex     string   `json:"regex,omitempty"`      // шаблон значения
+	MaxLength int      `json:"max_length,omitempty"` // максимальная длина значения
+	Enum      []string `json:"enum,omitempty"`       // список допустимых значений
+	Unique    bool     `json:"unique,omitempty"`     // значение уникально в пределах листа
+
+	date1904 bool // система дат 1904 в читаемом файле (заполняется при чтении)
 }
 
 // FieldsExcel структура для описания массива колонок excel-файла
@@ -60,10 +76,29 @@ type FieldsExcel struct {
 	sheetName string
 	fields    map[int]FieldExcel `yaml:"fields"` // int - используется как номер колонки (при импорте или выводе)
 	log       Logger
+
+	matchHeaders bool // сопоставлять поля с колонками по заголовкам
+	headerRow    int  // номер строки заголовка (0 - искать автоматически)
+
+	date1904 bool // в читаемой книге система дат 1904 (Mac Excel)
+
+	valueMode string // что читать из ячеек по умолчанию: raw, formatted, both (FieldExcel.Value)
+
+	format string      // формат файла (xlsx, xls, csv, tsv, json, ndjson), пусто - по расширению
+	csv    CSVOptions  // настройки CSV/TSV
+	json   JSONOptions // настройки JSON/NDJSON
+
+	save SaveOptions // настройки сохранения файлов
 }
 
 // NewFieldsExcel подготавливаем окончательно структуру для работы
 func NewFieldsExcel(sheetName string, fields map[int]FieldExcel, log Logger) FieldsExcel {
+	FieldsDefaults(fields)
+	return FieldsExcel{sheetName: sheetName, fields: fields, log: log}
+}
+
+// FieldsDefaults заполняем незаданные ширину колонки и форматы дат значениями по умолчанию
+func FieldsDefaults(fields map[int]FieldExcel) {
 	for k, v := range fields {
 
 		if v.Width == 0 { // если ширина не задана
@@ -71,24 +106,20 @@ func NewFieldsExcel(sheetName string, fields map[int]FieldExcel, log Logger) Fie
 			fields[k] = v
 		}
 
-		if v.Type == "date" && v.ParseFormat == "" { // если формат разбора даты не задан
-			if v.Format != "" {
-				tmp := strings.ToLower(v.Format)
-				tmp = strings.ReplaceAll(tmp, "dd", "02")
-				tmp = strings.ReplaceAll(tmp, "d", "2")
-				tmp = strings.ReplaceAll(tmp, "mm", "01")
-				tmp = strings.ReplaceAll(tmp, "m", "1")
-				tmp = strings.ReplaceAll(tmp, "yyyy", "2006")
-				tmp = strings.ReplaceAll(tmp, "yy", "06")
-				v.ParseFormat = tmp
-			} else {
-				v.ParseFormat = "02.01.2006"
-				v.Format = "dd.mm.yyyy"
+		if c, _ := converter(v.Type); c.Layout != "" && v.ParseFormat == "" { // если формат разбора даты или времени не задан
+			layout, err := excelLayout(v.Format)
+			switch {
+			case v.Format != "" && err == nil:
+				v.ParseFormat = layout
+			case v.Format != "": // формат не переводится в формат Go (прошедшее время [h]) - разбор по умолчанию
+				v.ParseFormat = c.Layout
+			default:
+				v.ParseFormat = c.Layout
+				v.Format = c.NumFmt
 			}
 			fields[k] = v
 		}
 	}
-	return FieldsExcel{sheetName: sheetName, fields: fields, log: log}
 }
 
 // CountColumn кол-во колонок
@@ -129,31 +160,20 @@ func NewFromJSON(sheetName string, text string, log Logger) (*FieldsExcel, error
 }
 
 // NewFromModelTags создание структуры описания колонок вывода Excel-файла
-// из тегов модели получаем нужные данные
+// из тегов модели получаем нужные данные, тип колонки (если не задан тегом type) - по типу поля
 func NewFromModelTags(f interface{}, log Logger) (FieldsExcel, error) {
-	var err error
 	fe := FieldsExcel{log: log, fields: make(map[int]FieldExcel)}
 
 	ft := reflect.TypeOf(f)
-	for i := 0; i < ft.NumField(); i++ {
-		curField := ft.Field(i)
-		//log.Debugf("%v %v %v %v %v", ft.Name(), curField.Name, curField.Type.Name(), curField.Tag.Get("header"), curField.Tag.Get("format"))
-
-		width := 10.0
-		tmp := strings.TrimSpace(curField.Tag.Get("width"))
-		if tmp != "" {
-			width, err = strconv.ParseFloat(tmp, 64)
-			if err != nil {
-				return fe, fmt.Errorf("width=<%v> name=<%v> : %w", tmp, curField.Name, err)
-			}
-		}
-
-		fe.fields[i+1] = FieldExcel{
-			Name:   curField.Tag.Get("db"),
-			Header: curField.Tag.Get("header"),
-			Width:  width,
-			Format: curField.Tag.Get("format"),
-		}
+	if ft == nil || ft.Kind() != reflect.Struct {
+		return fe, fmt.Errorf("модель должна быть структурой: %v", ft)
+	}
+	fields, err := modelFields(ft)
+	if err != nil {
+		return fe, err
+	}
+	for i, mf := range fields { // номер колонки - порядковый номер среди выводимых полей
+		fe.fields[i+1] = mf.field
 	}
 	return fe, nil
 }
@@ -163,12 +183,16 @@ func (s FieldsExcel) CreateStyle(f *excelize.File) error {
 	var err error
 	var styles = make(map[string]int)
 	for key, v := range s.fields {
-		if v.Format != "" {
-			style, ok := styles[v.Format]
+		format := v.Format
+		if c, ok := converter(v.Type); ok && format == "" {
+			format = c.NumFmt // формат по умолчанию для типа поля
+		}
+		if format != "" {
+			style, ok := styles[format]
 			if ok {
 				v.StyleID = style
 			} else {
-				switch v.Format { // https://xuri.me/excelize/ru/style.html#number_format
+				switch format { // https://xuri.me/excelize/ru/style.html#number_format
 				case "#,##0": // для целых чисел с разделителями тысяч
 					if style, err = f.NewStyle(&excelize.Style{NumFmt: 3, Lang: "ru-ru"}); err != nil {
 						return err
@@ -181,14 +205,30 @@ func (s FieldsExcel) CreateStyle(f *excelize.File) error {
 					if style, err = f.NewStyle(&excelize.Style{NumFmt: 14, Lang: "ru-ru"}); err != nil {
 						return err
 					}
+				case "0%": // проценты
+					if style, err = f.NewStyle(&excelize.Style{NumFmt: 9, Lang: "ru-ru"}); err != nil {
+						return err
+					}
+				case "0.00%":
+					if style, err = f.NewStyle(&excelize.Style{NumFmt: 10, Lang: "ru-ru"}); err != nil {
+						return err
+					}
+				case "[h]:mm:ss": // длительность, часы не ограничены сутками
+					if style, err = f.NewStyle(&excelize.Style{NumFmt: 46, Lang: "ru-ru"}); err != nil {
+						return err
+					}
+				case "@": // текст
+					if style, err = f.NewStyle(&excelize.Style{NumFmt: 49, Lang: "ru-ru"}); err != nil {
+						return err
+					}
 				default:
-					if style, err = f.NewStyle(&excelize.Style{CustomNumFmt: &v.Format, Lang: "ru-ru"}); err != nil {
+					if style, err = f.NewStyle(&excelize.Style{CustomNumFmt: &format, Lang: "ru-ru"}); err != nil {
 						return err
 					}
 				}
-				s.log.Debugf("Добавлен стиль в файл. %v=%v", style, v.Format)
-				styles[v.Format] = style
-				v.StyleID = styles[v.Format]
+				s.log.Debugf("Добавлен стиль в файл. %v=%v", style, format)
+				styles[format] = style
+				v.StyleID = styles[format]
 			}
 			s.fields[key] = v
 		}